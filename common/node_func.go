@@ -0,0 +1,110 @@
+package common
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"github.com/symphony09/running"
+	"github.com/symphony09/running/utils"
+)
+
+var (
+	contextType = reflect.TypeOf((*context.Context)(nil)).Elem()
+
+	errorType = reflect.TypeOf((*error)(nil)).Elem()
+)
+
+// FuncNode node run a function shaped like func(ctx context.Context, in In) (Out, error), In and Out are structs or struct pointers.
+// fields of In with tag `running:"prop:key"` are set from props when build, see utils.DecodeProps,
+// fields of In with tag `running:"in:key"` are set from state before call, see utils.ReadStateFields,
+// fields of Out with tag `running:"out:key"` are written to state if no error returned, see utils.WriteStateFields.
+// if inputs can not be set or the function returns error, the error is reported by running.ReportNodeError.
+type FuncNode struct {
+	running.Base
+
+	Func reflect.Value
+
+	// In input decoded from props, copied before set from state
+	In reflect.Value
+
+	InPtr bool
+}
+
+// NewFuncNodeBuilder return builder of FuncNode, builder returns error if fn is not shaped like FuncNode required
+func NewFuncNodeBuilder(fn interface{}) running.BuildNodeFunc {
+	fnVal := reflect.ValueOf(fn)
+	inType, inPtr, err := checkNodeFunc(fnVal)
+
+	return func(name string, props running.Props) (running.Node, error) {
+		if err != nil {
+			return nil, err
+		}
+
+		in := reflect.New(inType)
+		if err := utils.DecodeProps(props, name, in.Interface()); err != nil {
+			return nil, err
+		}
+
+		node := &FuncNode{Func: fnVal, In: in.Elem(), InPtr: inPtr}
+		node.SetName(name)
+
+		return node, nil
+	}
+}
+
+// checkNodeFunc check function shape, return struct type of input
+func checkNodeFunc(fn reflect.Value) (inType reflect.Type, inPtr bool, err error) {
+	if fn.Kind() != reflect.Func {
+		err = fmt.Errorf("func node handler expect func, got %v", fn.Kind())
+		return
+	}
+
+	fnType := fn.Type()
+	if fnType.IsVariadic() || fnType.NumIn() != 2 || fnType.NumOut() != 2 ||
+		fnType.In(0) != contextType || fnType.Out(1) != errorType {
+		err = fmt.Errorf("func node handler expect func(context.Context, In) (Out, error), got %v", fnType)
+		return
+	}
+
+	inType = fnType.In(1)
+	if inType.Kind() == reflect.Ptr {
+		inType, inPtr = inType.Elem(), true
+	}
+
+	outType := fnType.Out(0)
+	if outType.Kind() == reflect.Ptr {
+		outType = outType.Elem()
+	}
+
+	if inType.Kind() != reflect.Struct || outType.Kind() != reflect.Struct {
+		err = fmt.Errorf("func node handler expect struct or struct pointer as In and Out, got %v", fnType)
+	}
+
+	return
+}
+
+func (node *FuncNode) Run(ctx context.Context) {
+	in := reflect.New(node.In.Type())
+	in.Elem().Set(node.In)
+
+	if err := utils.ReadStateFields(node.State, in.Interface()); err != nil {
+		running.ReportNodeError(ctx, node.Name(), err)
+		return
+	}
+
+	arg := in
+	if !node.InPtr {
+		arg = in.Elem()
+	}
+
+	results := node.Func.Call([]reflect.Value{reflect.ValueOf(ctx), arg})
+	if err, _ := results[1].Interface().(error); err != nil {
+		running.ReportNodeError(ctx, node.Name(), err)
+		return
+	}
+
+	if out := results[0]; out.Kind() != reflect.Ptr || !out.IsNil() {
+		_ = utils.WriteStateFields(node.State, out.Interface())
+	}
+}
@@ -30,3 +30,29 @@ func (state *ChanState) Transform(key string, transform running.TransformStateFu
 	params[key] = transform(params[key])
 	state.ch <- params
 }
+
+func (state *ChanState) Delete(key string) {
+	params := <-state.ch
+	delete(params, key)
+	state.ch <- params
+}
+
+// Range iterate over a copy of params, params are put back to the chan before f is called, so f can access the state
+func (state *ChanState) Range(f func(key string, value interface{}) bool) {
+	for key, value := range state.Snapshot() {
+		if !f(key, value) {
+			return
+		}
+	}
+}
+
+func (state *ChanState) Snapshot() map[string]interface{} {
+	params := <-state.ch
+	snapshot := make(map[string]interface{}, len(params))
+	for key, value := range params {
+		snapshot[key] = value
+	}
+	state.ch <- params
+
+	return snapshot
+}
@@ -1,6 +1,9 @@
 package common
 
-import "github.com/symphony09/running"
+import (
+	"github.com/symphony09/running"
+	"github.com/symphony09/running/utils"
+)
 
 type HookState struct {
 	Base running.State
@@ -57,3 +60,62 @@ func (state *HookState) Transform(key string, transform running.TransformStateFu
 
 	state.Base.Transform(key, transform)
 }
+
+// CanDelete return true if Base supports Delete, see utils.PartialState
+func (state *HookState) CanDelete() bool {
+	return utils.CanDeleteState(state.Base)
+}
+
+// CanRange return true if Base supports Range or Snapshot, see utils.PartialState
+func (state *HookState) CanRange() bool {
+	return utils.CanRangeState(state.Base)
+}
+
+// Delete delete the key of Base if supported, see utils.DeleteState
+func (state *HookState) Delete(key string) {
+	for _, hook := range state.Hooks {
+		hook(state.Base, "delete", key, false)
+	}
+
+	defer func() {
+		for _, hook := range state.Hooks {
+			hook(state.Base, "delete", key, true)
+		}
+	}()
+
+	utils.DeleteState(state.Base, key)
+}
+
+// Range range over Base if supported, hooks are called with empty key, see utils.RangeState
+func (state *HookState) Range(f func(key string, value interface{}) bool) {
+	for _, hook := range state.Hooks {
+		hook(state.Base, "range", "", false)
+	}
+
+	defer func() {
+		for _, hook := range state.Hooks {
+			hook(state.Base, "range", "", true)
+		}
+	}()
+
+	utils.RangeState(state.Base, f)
+}
+
+// Snapshot copy Base if supported, otherwise return empty map, hooks are called with empty key, see utils.SnapshotState
+func (state *HookState) Snapshot() map[string]interface{} {
+	for _, hook := range state.Hooks {
+		hook(state.Base, "snapshot", "", false)
+	}
+
+	defer func() {
+		for _, hook := range state.Hooks {
+			hook(state.Base, "snapshot", "", true)
+		}
+	}()
+
+	if snapshot, ok := utils.SnapshotState(state.Base); ok {
+		return snapshot
+	}
+
+	return map[string]interface{}{}
+}
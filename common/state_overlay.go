@@ -1,6 +1,12 @@
 package common
 
-import "github.com/symphony09/running"
+import (
+	"github.com/symphony09/running"
+	"github.com/symphony09/running/utils"
+)
+
+// _Tombstone mark key deleted in Upper of OverlayState, hide the key of Lower
+type _Tombstone struct{}
 
 type OverlayState struct {
 	Upper running.State
@@ -18,6 +24,9 @@ func NewOverlayState(lower, upper running.State) running.State {
 
 func (state OverlayState) Query(key string) (value interface{}, exists bool) {
 	if value, exists = state.Upper.Query(key); exists {
+		if _, deleted := value.(_Tombstone); deleted {
+			return nil, false
+		}
 		return
 	} else {
 		value, exists = state.Lower.Query(key)
@@ -39,5 +48,55 @@ func (state OverlayState) Transform(key string, transform running.TransformState
 		}
 	}
 
-	state.Upper.Transform(key, transform)
+	state.Upper.Transform(key, func(from interface{}) interface{} {
+		if _, deleted := from.(_Tombstone); deleted {
+			from = nil
+		}
+
+		return transform(from)
+	})
+}
+
+// CanDelete always return true, keys are deleted by tombstones in Upper, see utils.PartialState
+func (state OverlayState) CanDelete() bool {
+	return true
+}
+
+// CanRange return true if both Lower and Upper support Range or Snapshot, see utils.PartialState
+func (state OverlayState) CanRange() bool {
+	return utils.CanRangeState(state.Lower) && utils.CanRangeState(state.Upper)
+}
+
+// Delete mark the key deleted in Upper, Lower is not changed
+func (state OverlayState) Delete(key string) {
+	state.Upper.Update(key, _Tombstone{})
+}
+
+// Range iterate over a snapshot, see Snapshot
+func (state OverlayState) Range(f func(key string, value interface{}) bool) {
+	for key, value := range state.Snapshot() {
+		if !f(key, value) {
+			return
+		}
+	}
+}
+
+// Snapshot merge snapshots of Lower and Upper, keys deleted in Upper are excluded.
+// layer not supports snapshot or range is skipped, see utils.SnapshotState
+func (state OverlayState) Snapshot() map[string]interface{} {
+	snapshot, ok := utils.SnapshotState(state.Lower)
+	if !ok {
+		snapshot = make(map[string]interface{})
+	}
+
+	upper, _ := utils.SnapshotState(state.Upper)
+	for key, value := range upper {
+		if _, deleted := value.(_Tombstone); deleted {
+			delete(snapshot, key)
+		} else {
+			snapshot[key] = value
+		}
+	}
+
+	return snapshot
 }
@@ -0,0 +1,292 @@
+package common
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/symphony09/running"
+)
+
+// ErrStateTypeNotRegistered type of state value is not registered when encode, or type name is unknown when decode
+var ErrStateTypeNotRegistered = errors.New("state type not registered")
+
+// StateTypeRegistry map go types of state values to names, so SerializableState can be decoded in other process
+type StateTypeRegistry struct {
+	sync.RWMutex
+
+	types map[string]reflect.Type
+
+	names map[reflect.Type]string
+}
+
+// DefaultStateTypes registry used by SerializableState if not specified
+var DefaultStateTypes = NewStateTypeRegistry()
+
+// NewStateTypeRegistry return a registry with basic types registered by their type names, example: "int", "[]string", "time.Time".
+// []interface{} and map[string]interface{} are not registered, types of their elements can not be kept when decoded.
+func NewStateTypeRegistry() *StateTypeRegistry {
+	registry := &StateTypeRegistry{
+		types: make(map[string]reflect.Type),
+		names: make(map[reflect.Type]string),
+	}
+
+	for _, value := range []interface{}{
+		"", false, 0, int8(0), int16(0), int32(0), int64(0),
+		uint(0), uint8(0), uint16(0), uint32(0), uint64(0), float32(0), float64(0),
+		[]byte{}, []string{}, []int{}, []float64{},
+		map[string]string{},
+		time.Duration(0), time.Time{},
+	} {
+		_ = registry.Register(reflect.TypeOf(value).String(), value)
+	}
+
+	return registry
+}
+
+// RegisterStateType register type of value by name to DefaultStateTypes
+func RegisterStateType(name string, value interface{}) error {
+	return DefaultStateTypes.Register(name, value)
+}
+
+// Register register type of value by name, pointer types are registered separately from their element types.
+// a name or type can only be registered once, register the same pair again is allowed.
+func (registry *StateTypeRegistry) Register(name string, value interface{}) error {
+	if name == "" || value == nil {
+		return fmt.Errorf("state type register requires name and non-nil value")
+	}
+
+	typ := reflect.TypeOf(value)
+
+	registry.Lock()
+	defer registry.Unlock()
+
+	if registered, ok := registry.types[name]; ok && registered != typ {
+		return fmt.Errorf("state type name %s already registered for %v", name, registered)
+	}
+
+	if registered, ok := registry.names[typ]; ok && registered != name {
+		return fmt.Errorf("state type %v already registered as %s", typ, registered)
+	}
+
+	registry.types[name] = typ
+	registry.names[typ] = name
+	return nil
+}
+
+// TypeName return registered name of value type
+func (registry *StateTypeRegistry) TypeName(value interface{}) (string, bool) {
+	registry.RLock()
+	defer registry.RUnlock()
+
+	name, ok := registry.names[reflect.TypeOf(value)]
+	return name, ok
+}
+
+// Type return type registered by name
+func (registry *StateTypeRegistry) Type(name string) (reflect.Type, bool) {
+	registry.RLock()
+	defer registry.RUnlock()
+
+	typ, ok := registry.types[name]
+	return typ, ok
+}
+
+// SerializableState state can be encoded to json or gob with names of value types, and decoded to values of the same types.
+// encode fails with ErrStateTypeNotRegistered if any value type is not registered, nil values are always allowed.
+// decode replaces all keys of the state, it should not run concurrently with other methods.
+type SerializableState struct {
+	*running.StandardState
+
+	Registry *StateTypeRegistry
+}
+
+// NewSerializableState return a SerializableState, DefaultStateTypes is used if registry is nil
+func NewSerializableState(registry *StateTypeRegistry) *SerializableState {
+	if registry == nil {
+		registry = DefaultStateTypes
+	}
+
+	return &SerializableState{StandardState: running.NewStandardState(), Registry: registry}
+}
+
+// NewSerializableStateBuilder return builder of SerializableState, can be set as Engine.StateBuilder
+func NewSerializableStateBuilder(registry *StateTypeRegistry) func() running.State {
+	return func() running.State {
+		return NewSerializableState(registry)
+	}
+}
+
+type _JsonStateValue struct {
+	Type string `json:",omitempty"`
+
+	Value json.RawMessage
+}
+
+type _GobStateValue struct {
+	Type string
+
+	Data []byte
+}
+
+// MarshalJSON encode state as {"key": {"Type": "int", "Value": 1}}
+func (state *SerializableState) MarshalJSON() ([]byte, error) {
+	encoded := make(map[string]_JsonStateValue)
+
+	err := state.encode(func(key, typeName string, value interface{}) error {
+		data, err := json.Marshal(value)
+		if err != nil {
+			return err
+		}
+
+		encoded[key] = _JsonStateValue{Type: typeName, Value: data}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(encoded)
+}
+
+func (state *SerializableState) UnmarshalJSON(data []byte) error {
+	var encoded map[string]_JsonStateValue
+	if err := json.Unmarshal(data, &encoded); err != nil {
+		return err
+	}
+
+	params := make(map[string]interface{}, len(encoded))
+	for key, item := range encoded {
+		value, err := state.decode(key, item.Type, func(ptr interface{}) error {
+			return json.Unmarshal(item.Value, ptr)
+		})
+		if err != nil {
+			return err
+		}
+
+		params[key] = value
+	}
+
+	state.replace(params)
+	return nil
+}
+
+// GobEncode encode each value by gob with its type name, types need not be registered to gob
+func (state *SerializableState) GobEncode() ([]byte, error) {
+	encoded := make(map[string]_GobStateValue)
+
+	err := state.encode(func(key, typeName string, value interface{}) error {
+		var buf bytes.Buffer
+		if typeName != "" {
+			if err := gob.NewEncoder(&buf).Encode(value); err != nil {
+				return err
+			}
+		}
+
+		encoded[key] = _GobStateValue{Type: typeName, Data: buf.Bytes()}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	if err = gob.NewEncoder(&buf).Encode(encoded); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func (state *SerializableState) GobDecode(data []byte) error {
+	var encoded map[string]_GobStateValue
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&encoded); err != nil {
+		return err
+	}
+
+	params := make(map[string]interface{}, len(encoded))
+	for key, item := range encoded {
+		value, err := state.decode(key, item.Type, func(ptr interface{}) error {
+			return gob.NewDecoder(bytes.NewReader(item.Data)).Decode(ptr)
+		})
+		if err != nil {
+			return err
+		}
+
+		params[key] = value
+	}
+
+	state.replace(params)
+	return nil
+}
+
+// encode call f with type name of each value in a snapshot by key order, type name of nil value is empty
+func (state *SerializableState) encode(f func(key, typeName string, value interface{}) error) error {
+	snapshot := state.Snapshot()
+
+	keys := make([]string, 0, len(snapshot))
+	for key := range snapshot {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		value := snapshot[key]
+
+		var typeName string
+		if value != nil {
+			var ok bool
+			if typeName, ok = state.registry().TypeName(value); !ok {
+				return fmt.Errorf("%w, key: %s, type: %T", ErrStateTypeNotRegistered, key, value)
+			}
+		}
+
+		if err := f(key, typeName, value); err != nil {
+			return fmt.Errorf("failed to encode state, key: %s, %w", key, err)
+		}
+	}
+
+	return nil
+}
+
+// decode value of registered type by the type name, empty type name for nil value
+func (state *SerializableState) decode(key, typeName string, unmarshal func(ptr interface{}) error) (interface{}, error) {
+	if typeName == "" {
+		return nil, nil
+	}
+
+	typ, ok := state.registry().Type(typeName)
+	if !ok {
+		return nil, fmt.Errorf("%w, key: %s, type: %s", ErrStateTypeNotRegistered, key, typeName)
+	}
+
+	ptr := reflect.New(typ)
+	if err := unmarshal(ptr.Interface()); err != nil {
+		return nil, fmt.Errorf("failed to decode state, key: %s, %w", key, err)
+	}
+
+	return ptr.Elem().Interface(), nil
+}
+
+func (state *SerializableState) replace(params map[string]interface{}) {
+	standard := running.NewStandardState()
+	for key, value := range params {
+		standard.Update(key, value)
+	}
+
+	state.StandardState = standard
+}
+
+func (state *SerializableState) registry() *StateTypeRegistry {
+	if state.Registry == nil {
+		return DefaultStateTypes
+	}
+
+	return state.Registry
+}
@@ -22,3 +22,25 @@ func (state *UnsafeState) Update(key string, value interface{}) {
 func (state *UnsafeState) Transform(key string, transform running.TransformStateFunc) {
 	state.params[key] = transform(state.params[key])
 }
+
+func (state *UnsafeState) Delete(key string) {
+	delete(state.params, key)
+}
+
+// Range iterate over params directly, keys added in f may not be visited
+func (state *UnsafeState) Range(f func(key string, value interface{}) bool) {
+	for key, value := range state.params {
+		if !f(key, value) {
+			return
+		}
+	}
+}
+
+func (state *UnsafeState) Snapshot() map[string]interface{} {
+	snapshot := make(map[string]interface{}, len(state.params))
+	for key, value := range state.params {
+		snapshot[key] = value
+	}
+
+	return snapshot
+}
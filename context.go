@@ -1,5 +1,10 @@
 package running
 
+import (
+	"context"
+	"sync"
+)
+
 type ctxKey string
 
 var CtxKey ctxKey = "rck"
@@ -18,4 +23,44 @@ type CtxParams struct {
 	MatchOneOfLabels []string
 
 	State State
+
+	// PropsOverride props of this execution keyed by node path, "" for all nodes, see NestedProps.
+	// vertexes with overridden props or sub-nodes are rebuilt, pooled workers are not changed.
+	PropsOverride NestedProps
+}
+
+type _NodeErrorsKey struct{}
+
+// _NodeErrors errors reported by nodes of a worker run, only the first one is kept
+type _NodeErrors struct {
+	err error
+
+	sync.Mutex
+}
+
+func (errs *_NodeErrors) report(err error) {
+	errs.Lock()
+	if errs.err == nil {
+		errs.err = err
+	}
+	errs.Unlock()
+}
+
+func (errs *_NodeErrors) first() error {
+	errs.Lock()
+	defer errs.Unlock()
+	return errs.err
+}
+
+// ReportNodeError report error of node without panic, safe to call in goroutines of clusters.
+// the first reported error is set to Output.Err as NodeError and the worker is terminated after the vertex done, like panic.
+// do nothing if ctx is not passed by the engine.
+func ReportNodeError(ctx context.Context, nodeName string, err error) {
+	if err == nil {
+		return
+	}
+
+	if errs, ok := ctx.Value(_NodeErrorsKey{}).(*_NodeErrors); ok {
+		errs.report(&NodeError{NodeName: nodeName, Err: err})
+	}
 }
@@ -46,6 +46,14 @@ type Cloneable interface {
 	Clone() Node
 }
 
+// Reconfigurable a class of nodes that can apply new props without rebuild, see Engine.UpdatePlanProps
+type Reconfigurable interface {
+	Node
+
+	// Reconfigure apply props as builder does, node is rebuilt if error returned
+	Reconfigure(props Props) error
+}
+
 // Reversible a class of nodes that can be reverted
 type Reversible interface {
 	Node
@@ -86,6 +94,30 @@ type State interface {
 
 type TransformStateFunc func(from interface{}) interface{}
 
+// Deleter a class of states that can delete keys
+type Deleter interface {
+	State
+
+	// Delete remove the key, Query return false for it after that
+	Delete(key string)
+}
+
+// Ranger a class of states that can iterate over keys
+type Ranger interface {
+	State
+
+	// Range call f for each key and value until f returns false, f can update state while ranging
+	Range(f func(key string, value interface{}) bool)
+}
+
+// Snapshotter a class of states that can take a consistent copy
+type Snapshotter interface {
+	State
+
+	// Snapshot return a copy of all keys and values at one moment
+	Snapshot() map[string]interface{}
+}
+
 type Output struct {
 	Err error
 
@@ -165,3 +165,30 @@ func (state *StandardState) Transform(key string, transform TransformStateFunc)
 	state.params[key] = transform(state.params[key])
 	state.Unlock()
 }
+
+func (state *StandardState) Delete(key string) {
+	state.Lock()
+	delete(state.params, key)
+	state.Unlock()
+}
+
+// Range iterate over a snapshot, so updates in f are not visible
+func (state *StandardState) Range(f func(key string, value interface{}) bool) {
+	for key, value := range state.Snapshot() {
+		if !f(key, value) {
+			return
+		}
+	}
+}
+
+func (state *StandardState) Snapshot() map[string]interface{} {
+	state.RLock()
+	defer state.RUnlock()
+
+	snapshot := make(map[string]interface{}, len(state.params))
+	for key, value := range state.params {
+		snapshot[key] = value
+	}
+
+	return snapshot
+}
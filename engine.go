@@ -5,23 +5,44 @@ import (
 	"encoding/json"
 	"fmt"
 	"runtime/debug"
-	"strconv"
+	"sort"
+	"strings"
 	"sync"
-	"time"
 )
 
 type Engine struct {
 	StateBuilder func() State
 
+	// StrictRegistration reject plans with lint issues when register or load plan, see LintPlan
+	StrictRegistration bool
+
+	// PreflightBuild build one worker when register or load plan,
+	// reject the plan with BuildError if failed, the worker is kept in pool for later use
+	PreflightBuild bool
+
+	// PropsResolver resolve references like ${env:DB_URL} in props values when build worker,
+	// DefaultPropsResolver is used if nil
+	PropsResolver PropsResolveFunc
+
 	builders map[string]BuildNodeFunc
 
 	buildersInfo map[string]NodeBuilderInfo
 
+	buildersProps map[string][]PropSchema
+
 	plans map[string]*Plan
 
 	pools map[string]*_WorkerPool
 
+	loadErrors map[string]PlanLoadError
+
+	templates map[string]*_PlanTemplate
+
 	buildersLocker, plansLocker, poolsLocker sync.RWMutex
+
+	loadErrorsLocker sync.Mutex
+
+	patchLocker, templatesLocker sync.Mutex
 }
 
 // RegisterNodeBuilder register node builder to engine
@@ -31,16 +52,34 @@ func (engine *Engine) RegisterNodeBuilder(name string, builder BuildNodeFunc) {
 	engine.buildersLocker.Unlock()
 }
 
+// UnregisterNodeBuilder remove node builder and its info from engine.
+// if any registered plan still references the builder as node type or wrapper,
+// return ErrNodeBuilderInUse unless force is true.
+func (engine *Engine) UnregisterNodeBuilder(name string, force bool) error {
+	if !force {
+		if users := engine.plansUsingBuilder(name); len(users) > 0 {
+			return fmt.Errorf("%w, builder: %s, used by plans: %v", ErrNodeBuilderInUse, name, users)
+		}
+	}
+
+	engine.buildersLocker.Lock()
+	delete(engine.builders, name)
+	delete(engine.buildersInfo, name)
+	delete(engine.buildersProps, name)
+	engine.buildersLocker.Unlock()
+	return nil
+}
+
 // RegisterPlan register plan to engine
 func (engine *Engine) RegisterPlan(name string, plan *Plan) error {
+	engine.bindBase(name, plan)
+
 	err := plan.Init()
 	if err != nil {
 		return err
 	}
-	engine.plansLocker.Lock()
-	engine.plans[name] = plan
-	engine.plansLocker.Unlock()
-	return nil
+
+	return engine.addPlan(name, plan)
 }
 
 // LoadPlanFromJson load plan from json data
@@ -54,15 +93,139 @@ func (engine *Engine) LoadPlanFromJson(name string, jsonData []byte, prebuilt []
 		return err
 	}
 
-	plan.prebuilt = make(map[string]Node)
-	for _, node := range prebuilt {
-		plan.prebuilt[node.Name()] = node
+	if len(prebuilt) > 0 || plan.Base != "" {
+		plan.Prebuilt = prebuilt
+		engine.bindBase(name, plan)
+		if err = plan.Init(); err != nil {
+			return err
+		}
+	}
+
+	return engine.addPlan(name, plan)
+}
+
+// addPlan check initialized plan according to engine settings, then add it to engine
+func (engine *Engine) addPlan(name string, plan *Plan) error {
+	if err := engine.validatePlanProps(plan); err != nil {
+		return err
+	}
+
+	if engine.StrictRegistration {
+		if issues := engine.lintPlan(plan); len(issues) > 0 {
+			return issues
+		}
+	}
+
+	var worker *_Worker
+	if engine.PreflightBuild {
+		var err error
+		if worker, err = engine.buildPlanWorker(plan); err != nil {
+			return err
+		}
 	}
-	plan.version = strconv.FormatInt(time.Now().Unix(), 10)
 
 	engine.plansLocker.Lock()
+	_, replaced := engine.plans[name]
 	engine.plans[name] = plan
 	engine.plansLocker.Unlock()
+
+	// drop workers of the replaced plan before pooling the preflight worker
+	if replaced {
+		engine.ClearPool(name)
+	}
+
+	if worker != nil {
+		engine.getPool(name).PutWorker(worker)
+	}
+
+	return engine.resolveDependants(name)
+}
+
+// bindBase set option to inherit base plan from engine, base plan is resolved when plan init
+func (engine *Engine) bindBase(name string, plan *Plan) {
+	plan.locker.Lock()
+	defer plan.locker.Unlock()
+
+	switch plan.Base {
+	case "":
+		plan.inherit = nil
+	case name:
+		plan.inherit = func(dag *_DAG) {
+			dag.Warning = append(dag.Warning, fmt.Sprintf("plan %s can not inherit itself", name))
+		}
+	default:
+		plan.inherit = engine.IncludePlan(plan.Base, "")
+	}
+}
+
+// resolveDependants init plans inherit the base plan again, including indirect ones, so updates of base plan take effect
+func (engine *Engine) resolveDependants(base string) error {
+	var errs []string
+
+	visited := map[string]bool{base: true}
+	queue := []string{base}
+
+	for len(queue) > 0 {
+		// copy plans first, plan lock must not be taken while holding plans lock,
+		// Plan.Init takes plans lock while holding plan lock
+		engine.plansLocker.RLock()
+		all := make(map[string]*Plan, len(engine.plans))
+		for name, plan := range engine.plans {
+			all[name] = plan
+		}
+		engine.plansLocker.RUnlock()
+
+		var names []string
+		plans := make(map[string]*Plan)
+
+		for name, plan := range all {
+			plan.locker.RLock()
+			if plan.Base == queue[0] && !visited[name] {
+				names = append(names, name)
+				plans[name] = plan
+			}
+			plan.locker.RUnlock()
+		}
+
+		queue = queue[1:]
+		sort.Strings(names)
+
+		for _, name := range names {
+			visited[name] = true
+
+			if err := plans[name].Init(); err != nil {
+				errs = append(errs, fmt.Sprintf("%s: %s", name, err))
+				continue
+			}
+
+			engine.ClearPool(name)
+			queue = append(queue, name)
+		}
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("failed to resolve plans based on %s, %s", base, strings.Join(errs, "; "))
+	}
+
+	return nil
+}
+
+// RemovePlan remove plan from engine and drain its worker pool
+func (engine *Engine) RemovePlan(name string) error {
+	engine.plansLocker.Lock()
+	if _, ok := engine.plans[name]; !ok {
+		engine.plansLocker.Unlock()
+		return fmt.Errorf("%w, plan: %s", ErrPlanNotFound, name)
+	}
+	delete(engine.plans, name)
+	engine.plansLocker.Unlock()
+
+	engine.poolsLocker.Lock()
+	if pool := engine.pools[name]; pool != nil {
+		pool.Drain()
+	}
+	delete(engine.pools, name)
+	engine.poolsLocker.Unlock()
 	return nil
 }
 
@@ -86,30 +249,7 @@ func (engine *Engine) ExecPlan(name string, ctx context.Context) <-chan Output {
 			return
 		}
 
-		engine.poolsLocker.RLock()
-		pool := engine.pools[name]
-		engine.poolsLocker.RUnlock()
-
-		// set worker pool for new plan
-		if pool == nil {
-			engine.poolsLocker.Lock()
-			if engine.pools[name] == nil {
-				engine.pools[name] = &_WorkerPool{
-					Pool: sync.Pool{
-						New: func() interface{} {
-							worker, err := engine.buildWorker(name)
-							if err != nil {
-								return fmt.Errorf("%w, err: %s", ErrBuildWorkerFailed, err)
-							} else {
-								return worker
-							}
-						},
-					},
-				}
-			}
-			pool = engine.pools[name]
-			engine.poolsLocker.Unlock()
-		}
+		pool := engine.getPool(name)
 
 		// get worker from pool and work
 		worker, err := pool.GetWorker()
@@ -118,21 +258,76 @@ func (engine *Engine) ExecPlan(name string, ctx context.Context) <-chan Output {
 			outputCh <- output
 			return
 		}
-		output = <-worker.Work(ctx)
-		outputCh <- output
 
-		// if the plan has not been updated, reuse the worker
+		// apply props updated by UpdatePlanProps
+		if err = engine.reconfigureWorker(plan, worker); err != nil {
+			output.Err = err
+			outputCh <- output
+			return
+		}
+
+		// rebuild nodes with props override in a copy of the worker, the pooled one is not changed
+		work := worker
+		if params, ok := ctx.Value(CtxKey).(CtxParams); ok && len(params.PropsOverride) > 0 {
+			work, err = engine.overrideWorker(plan, worker, params.PropsOverride)
+		}
+
+		if err != nil {
+			output.Err = err
+		} else {
+			output = <-work.Work(ctx)
+		}
+
+		// if the plan has not been updated, reuse the worker.
+		// worker is put back before output sent, so exec after output received can check it out again
 		plan.locker.RLock()
 		version := plan.version
 		plan.locker.RUnlock()
 		if worker.Version == version {
 			pool.PutWorker(worker)
 		}
+
+		outputCh <- output
 	}()
 
 	return outputCh
 }
 
+// getPool get worker pool of plan, set a new one if not exists
+func (engine *Engine) getPool(name string) *_WorkerPool {
+	engine.poolsLocker.RLock()
+	pool := engine.pools[name]
+	engine.poolsLocker.RUnlock()
+
+	if pool != nil {
+		return pool
+	}
+
+	engine.poolsLocker.Lock()
+	defer engine.poolsLocker.Unlock()
+
+	if engine.pools[name] == nil {
+		engine.pools[name] = &_WorkerPool{
+			Pool: sync.Pool{
+				New: func() interface{} {
+					worker, err := engine.buildWorker(name)
+					if err != nil {
+						if _, ok := err.(*BuildError); ok {
+							return err
+						}
+
+						return fmt.Errorf("%w, err: %s", ErrBuildWorkerFailed, err)
+					} else {
+						return worker
+					}
+				},
+			},
+		}
+	}
+
+	return engine.pools[name]
+}
+
 // UpdatePlan update plan register in engine
 func (engine *Engine) UpdatePlan(name string, update func(plan *Plan)) error {
 	engine.plansLocker.RLock()
@@ -143,12 +338,74 @@ func (engine *Engine) UpdatePlan(name string, update func(plan *Plan)) error {
 	update(plan)
 	plan.locker.Unlock()
 
+	engine.bindBase(name, plan)
+
 	err := plan.Init()
 	if err != nil {
 		return err
 	}
 
-	return nil
+	if err = engine.validatePlanProps(plan); err != nil {
+		return err
+	}
+
+	return engine.resolveDependants(name)
+}
+
+// PatchPlan apply RFC 6902 JSON Patch to the json form of plan register in engine, see ExportPlan.
+// the patched plan is validated and checked against registered builders before it replaces the old one,
+// the plan is unchanged if any step failed. prebuilt nodes of the old plan are kept.
+// json form of plan is resolved, so a plan inheriting base plan become independent after patched.
+// secret props are masked in json form, they are kept if the masked values are not changed by patch.
+func (engine *Engine) PatchPlan(name string, patch []byte) error {
+	engine.patchLocker.Lock()
+	defer engine.patchLocker.Unlock()
+
+	engine.plansLocker.RLock()
+	plan := engine.plans[name]
+	engine.plansLocker.RUnlock()
+
+	if plan == nil {
+		return fmt.Errorf("%w, plan: %s", ErrPlanNotFound, name)
+	}
+
+	data, err := json.Marshal(plan)
+	if err != nil {
+		return err
+	}
+
+	if data, err = ApplyJsonPatch(data, patch); err != nil {
+		return err
+	}
+
+	patched := &Plan{}
+	if err = patched.unmarshalJSON(data, plan); err != nil {
+		return err
+	}
+
+	plan.locker.RLock()
+	prebuilt := plan.Prebuilt
+	plan.locker.RUnlock()
+
+	if len(prebuilt) > 0 {
+		patched.Prebuilt = prebuilt
+		if err = patched.Init(); err != nil {
+			return err
+		}
+	}
+
+	var missing LintIssues
+	for _, issue := range engine.lintPlan(patched) {
+		if issue.Rule == LintRuleMissingBuilder || issue.Rule == LintRuleMissingWrapper {
+			missing = append(missing, issue)
+		}
+	}
+
+	if len(missing) > 0 {
+		return missing
+	}
+
+	return engine.addPlan(name, patched)
 }
 
 func (engine *Engine) ExportPlan(name string) ([]byte, error) {
@@ -197,11 +454,29 @@ func (engine *Engine) SetNodeBuilderInfo(name string, info NodeBuilderInfo) {
 	engine.buildersLocker.Unlock()
 }
 
-func (engine *Engine) buildWorker(name string) (worker *_Worker, err error) {
+// SetNodeBuilderProps declare props of the builder, used to validate plan props and set defaults
+func (engine *Engine) SetNodeBuilderProps(name string, props []PropSchema) {
+	engine.buildersLocker.Lock()
+	if engine.buildersProps == nil {
+		engine.buildersProps = make(map[string][]PropSchema)
+	}
+	engine.buildersProps[name] = props
+	engine.buildersLocker.Unlock()
+}
+
+func (engine *Engine) buildWorker(name string) (*_Worker, error) {
 	engine.plansLocker.RLock()
 	plan := engine.plans[name]
 	engine.plansLocker.RUnlock()
 
+	if plan == nil {
+		return nil, ErrPlanNotFound
+	}
+
+	return engine.buildPlanWorker(plan)
+}
+
+func (engine *Engine) buildPlanWorker(plan *Plan) (worker *_Worker, err error) {
 	plan.locker.RLock()
 	defer plan.locker.RUnlock()
 
@@ -213,7 +488,13 @@ func (engine *Engine) buildWorker(name string) (worker *_Worker, err error) {
 		}
 	}()
 
+	props, groups, err := engine.resolvePlanProps(plan)
+	if err != nil {
+		return
+	}
+
 	nodeMap := map[string]Node{}
+	parts := map[string][]_NodePart{}
 	reuse := map[string]Node{} // collect nodes which can be reused in the build nodes process
 
 	for _, v := range plan.graph.Vertexes {
@@ -222,10 +503,12 @@ func (engine *Engine) buildWorker(name string) (worker *_Worker, err error) {
 		}
 
 		nodeName := v.RefRoot.NodeName
-		nodeMap[nodeName], err = engine.buildNode(plan, nodeName, "", reuse)
+		var vertexParts []_NodePart
+		nodeMap[nodeName], err = engine.buildNode(plan, props, groups, plan.prebuilt, nodeName, "", reuse, &vertexParts)
 		if err != nil {
 			return
 		}
+		parts[nodeName] = vertexParts
 	}
 
 	if len(reuse) > 0 {
@@ -243,24 +526,35 @@ func (engine *Engine) buildWorker(name string) (worker *_Worker, err error) {
 		Nodes:        nodeMap,
 		StateBuilder: engine.StateBuilder,
 		Version:      plan.version,
+		PropsVersion: plan.propsVersion,
+		Parts:        parts,
 	}
 	return
 }
 
-// buildNode build node by ref, props and prebuilt nodes.
+// _NodePart node built by builder for node path, Builder is node type or wrapper
+type _NodePart struct {
+	Path, Builder string
+
+	Ref *_NodeRef
+
+	Node Node
+
+	// Prebuilt node is cloned from prebuilt nodes instead of built
+	Prebuilt bool
+}
+
+// buildNode build node by ref, props, props groups and prebuilt nodes.
 // prefix will be added to node name,
 // example: prefix = ClusterA, node name = SubNodeB => ClusterA.SubNodeB
-func (engine *Engine) buildNode(plan *Plan, nodeName string, prefix string, reuse map[string]Node) (Node, error) {
+// nodes built for the node, sub-nodes and wrappers are appended to parts if not nil.
+func (engine *Engine) buildNode(plan *Plan, props Props, groups []_PropsGroup, prebuilt map[string]Node,
+	nodeName string, prefix string, reuse map[string]Node, parts *[]_NodePart) (Node, error) {
 	engine.buildersLocker.RLock()
 	defer engine.buildersLocker.RUnlock()
 
 	root := plan.graph.NodeRefs[nodeName]
-	props := plan.props
-	prebuilt := plan.prebuilt
-
-	if props == nil {
-		props = EmptyProps{}
-	}
+	planProps := props // props without props groups, for sub-nodes
 
 	var rootNode Node
 	var err error
@@ -271,16 +565,16 @@ func (engine *Engine) buildNode(plan *Plan, nodeName string, prefix string, reus
 		nodeName = root.NodeName
 	}
 
+	props = withPropsGroups(groups, props, nodeName, root)
+
 	// prefer to use pre-built nodes
 	if node := getPrebuiltNode(prebuilt, nodeName); node != nil {
 		rootNode = node
-	} else if builder := engine.builders[root.NodeType]; builder != nil {
-		rootNode, err = builder(nodeName, props)
-		if err != nil {
-			return nil, fmt.Errorf("failed to build %s, err=%s", nodeName, err.Error())
-		}
+		appendNodePart(parts, _NodePart{Path: nodeName, Builder: root.NodeType, Ref: root, Node: rootNode, Prebuilt: true})
+	} else if rootNode, err = engine.callBuilder(nodeName, root.NodeType, props); err != nil {
+		return nil, err
 	} else {
-		return nil, fmt.Errorf("no builder found for type %s", root.NodeType)
+		appendNodePart(parts, _NodePart{Path: nodeName, Builder: root.NodeType, Ref: root, Node: rootNode})
 	}
 
 	if root.ReUse {
@@ -306,35 +600,30 @@ func (engine *Engine) buildNode(plan *Plan, nodeName string, prefix string, reus
 			var subNode Node
 
 			if len(ref.SubRefs) == 0 {
-				if node := getPrebuiltNode(prebuilt, rootNode.Name()+"."+ref.NodeName); node != nil {
+				subNodeName := rootNode.Name() + "." + ref.NodeName
+				subProps := withPropsGroups(groups, planProps, subNodeName, ref)
+
+				if node := getPrebuiltNode(prebuilt, subNodeName); node != nil {
 					subNode = node
-				} else if builder := engine.builders[ref.NodeType]; builder != nil {
-					subNode, err = builder(rootNode.Name()+"."+ref.NodeName, props)
-					if err != nil {
-						return nil, fmt.Errorf("failed to build %s, err=%s", nodeName, err.Error())
-					}
+					appendNodePart(parts, _NodePart{Path: subNodeName, Builder: ref.NodeType, Ref: ref, Node: subNode, Prebuilt: true})
+				} else if subNode, err = engine.callBuilder(subNodeName, ref.NodeType, subProps); err != nil {
+					return nil, err
 				} else {
-					return nil, fmt.Errorf("no builder found for type %s", ref.NodeType)
+					appendNodePart(parts, _NodePart{Path: subNodeName, Builder: ref.NodeType, Ref: ref, Node: subNode})
 				}
 
-				if ref.ReUse && prebuilt[rootNode.Name()+"."+ref.NodeName] == nil {
-					reuse[rootNode.Name()+"."+ref.NodeName] = subNode
+				if ref.ReUse && prebuilt[subNodeName] == nil {
+					reuse[subNodeName] = subNode
 				}
 
-				subNode, err = engine.wrapNode(subNode, ref.Wrappers, props)
+				subNode, err = engine.wrapNode(subNode, subNodeName, ref, subProps, parts)
 				if err != nil {
 					return nil, err
 				}
 
 				subNodes = append(subNodes, subNode)
 			} else {
-				if prefix == "" {
-					prefix = root.NodeName
-				} else {
-					prefix = prefix + "." + root.NodeName
-				}
-
-				if subNode, err = engine.buildNode(plan, ref.NodeName, prefix, reuse); err != nil {
+				if subNode, err = engine.buildNode(plan, planProps, groups, prebuilt, ref.NodeName, nodeName, reuse, parts); err != nil {
 					return nil, err
 				} else {
 					subNodes = append(subNodes, subNode)
@@ -345,7 +634,7 @@ func (engine *Engine) buildNode(plan *Plan, nodeName string, prefix string, reus
 		cluster.Inject(subNodes)
 	}
 
-	rootNode, err = engine.wrapNode(rootNode, root.Wrappers, props)
+	rootNode, err = engine.wrapNode(rootNode, nodeName, root, props, parts)
 	if err != nil {
 		return nil, err
 	}
@@ -353,16 +642,32 @@ func (engine *Engine) buildNode(plan *Plan, nodeName string, prefix string, reus
 	return rootNode, nil
 }
 
-func (engine *Engine) wrapNode(target Node, wrappers []string, props Props) (Node, error) {
-	for _, wrapper := range wrappers {
+// callBuilder build node by builder of the node type, return BuildError if failed.
+// caller should hold the builders lock.
+func (engine *Engine) callBuilder(nodePath, nodeType string, props Props) (Node, error) {
+	builder := engine.builders[nodeType]
+	if builder == nil {
+		return nil, &BuildError{NodePath: nodePath, NodeType: nodeType, Builder: nodeType, Err: ErrNodeBuilderNotFound}
+	}
+
+	node, err := builder(nodePath, engine.withPropsDefaults(props, nodePath, nodeType))
+	if err != nil {
+		return nil, &BuildError{NodePath: nodePath, NodeType: nodeType, Builder: nodeType, Err: err}
+	}
+
+	return node, nil
+}
+
+func (engine *Engine) wrapNode(target Node, nodePath string, ref *_NodeRef, props Props, parts *[]_NodePart) (Node, error) {
+	for _, wrapper := range ref.Wrappers {
 		if builder := engine.builders[wrapper]; builder != nil {
-			node, err := builder(target.Name(), props)
+			node, err := builder(target.Name(), engine.withPropsDefaults(props, target.Name(), wrapper))
 			if err != nil {
-				return nil, fmt.Errorf("failed to build wrapper, wrapper name: %s, target name: %s, err: %s",
-					wrapper, target.Name(), err)
+				return nil, &BuildError{NodePath: nodePath, NodeType: ref.NodeType, Builder: wrapper, Err: err}
 			}
 
 			if wrapperNode, ok := node.(Wrapper); ok {
+				appendNodePart(parts, _NodePart{Path: target.Name(), Builder: wrapper, Ref: ref, Node: wrapperNode})
 				wrapperNode.Wrap(target)
 				target = wrapperNode
 			}
@@ -372,6 +677,34 @@ func (engine *Engine) wrapNode(target Node, wrappers []string, props Props) (Nod
 	return target, nil
 }
 
+// plansUsingBuilder return names of plans which reference the builder as node type or wrapper
+func (engine *Engine) plansUsingBuilder(builder string) []string {
+	engine.plansLocker.RLock()
+	plans := make(map[string]*Plan, len(engine.plans))
+	for name, plan := range engine.plans {
+		plans[name] = plan
+	}
+	engine.plansLocker.RUnlock()
+
+	var users []string
+	for name, plan := range plans {
+		plan.locker.RLock()
+		if plan.graph != nil && plan.graph.UseBuilder(builder) {
+			users = append(users, name)
+		}
+		plan.locker.RUnlock()
+	}
+
+	sort.Strings(users)
+	return users
+}
+
+func appendNodePart(parts *[]_NodePart, part _NodePart) {
+	if parts != nil {
+		*parts = append(*parts, part)
+	}
+}
+
 func getPrebuiltNode(prebuilt map[string]Node, nodeName string) Node {
 	var node Node
 
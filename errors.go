@@ -2,6 +2,7 @@ package running
 
 import (
 	"errors"
+	"fmt"
 )
 
 var (
@@ -10,4 +11,58 @@ var (
 	ErrBuildWorkerFailed = errors.New("build worker failed")
 
 	ErrWorkerPanic = errors.New("worker panic")
+
+	ErrNodeFailed = errors.New("node failed")
+
+	ErrNodeBuilderInUse = errors.New("node builder in use")
+
+	ErrNodeBuilderNotFound = errors.New("node builder not found")
+
+	ErrPlanTemplateNotFound = errors.New("plan template not found")
 )
+
+// BuildError failure of building a node or its wrapper, it is also ErrBuildWorkerFailed
+type BuildError struct {
+	// NodePath path of the node, example: ClusterA.SubNodeB
+	NodePath string
+
+	NodeType string
+
+	// Builder name of the failed builder, it is the wrapper name if wrapper failed to build
+	Builder string
+
+	Err error
+}
+
+func (err *BuildError) Error() string {
+	return fmt.Sprintf("%s, node: %s, type: %s, builder: %s, err: %v",
+		ErrBuildWorkerFailed, err.NodePath, err.NodeType, err.Builder, err.Err)
+}
+
+func (err *BuildError) Unwrap() error {
+	return err.Err
+}
+
+func (err *BuildError) Is(target error) bool {
+	return target == ErrBuildWorkerFailed
+}
+
+// NodeError error reported by ReportNodeError, it is also ErrNodeFailed
+type NodeError struct {
+	// NodeName name of the node, example: ClusterA.SubNodeB
+	NodeName string
+
+	Err error
+}
+
+func (err *NodeError) Error() string {
+	return fmt.Sprintf("%s, node name: %s, err: %v", ErrNodeFailed, err.NodeName, err.Err)
+}
+
+func (err *NodeError) Unwrap() error {
+	return err.Err
+}
+
+func (err *NodeError) Is(target error) bool {
+	return target == ErrNodeFailed
+}
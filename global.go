@@ -23,11 +23,22 @@ func RegisterNodeBuilder(name string, builder BuildNodeFunc) {
 	Global.RegisterNodeBuilder(name, builder)
 }
 
+// UnregisterNodeBuilder remove node builder from Global,
+// fail if any plan still references it unless force is true
+func UnregisterNodeBuilder(name string, force bool) error {
+	return Global.UnregisterNodeBuilder(name, force)
+}
+
 // RegisterPlan register plan to Global
 func RegisterPlan(name string, plan *Plan) error {
 	return Global.RegisterPlan(name, plan)
 }
 
+// RemovePlan remove plan register in Global and drain its worker pool
+func RemovePlan(name string) error {
+	return Global.RemovePlan(name)
+}
+
 // ExecPlan exec plan register in Global
 func ExecPlan(name string, ctx context.Context) <-chan Output {
 	return Global.ExecPlan(name, ctx)
@@ -38,11 +49,46 @@ func UpdatePlan(name string, update func(plan *Plan)) error {
 	return Global.UpdatePlan(name, update)
 }
 
+// RegisterPlanTemplate register plan template to Global
+func RegisterPlanTemplate(name string, template PlanTemplate) error {
+	return Global.RegisterPlanTemplate(name, template)
+}
+
+// InstantiatePlan render plan template register in Global with params, then load it as plan into Global
+func InstantiatePlan(template, name string, params map[string]interface{}) error {
+	return Global.InstantiatePlan(template, name, params)
+}
+
+// IncludePlan copy plan register in Global with prefix, see Engine.IncludePlan
+func IncludePlan(planName, prefix string, options ...IncludeOption) Option {
+	return Global.IncludePlan(planName, prefix, options...)
+}
+
+// PatchPlan apply RFC 6902 JSON Patch to plan register in Global
+func PatchPlan(name string, patch []byte) error {
+	return Global.PatchPlan(name, patch)
+}
+
+// UpdatePlanProps replace props of plan register in Global without rebuilding the graph
+func UpdatePlanProps(name string, props Props) error {
+	return Global.UpdatePlanProps(name, props)
+}
+
+// LintPlan check plan register in Global against registered builders
+func LintPlan(name string) (LintIssues, error) {
+	return Global.LintPlan(name)
+}
+
 // ExportPlan export plan register in Global, return json bytes
 func ExportPlan(name string) ([]byte, error) {
 	return Global.ExportPlan(name)
 }
 
+// ExportPlanDSL export plan register in Global as plan DSL
+func ExportPlanDSL(name string) (string, error) {
+	return Global.ExportPlanDSL(name)
+}
+
 // WarmupPool warm up pool to avoid cold start
 // name: plan name
 // size: set size of worker buf queue
@@ -64,7 +110,22 @@ func LoadPlanFromJson(name string, jsonData []byte, prebuilt []Node) error {
 	return Global.LoadPlanFromJson(name, jsonData, prebuilt)
 }
 
+// LoadPlansFromStore load all plans from store to Global
+func LoadPlansFromStore(store PlanStore) error {
+	return Global.LoadPlansFromStore(store)
+}
+
+// WatchPlanStore load all plans from store to Global, then apply changes of store until ctx done
+func WatchPlanStore(ctx context.Context, store PlanStore) error {
+	return Global.WatchPlanStore(ctx, store)
+}
+
 // SetNodeBuilderInfo set meta info of node builder
 func SetNodeBuilderInfo(name string, info NodeBuilderInfo) {
 	Global.SetNodeBuilderInfo(name, info)
 }
+
+// SetNodeBuilderProps declare props of node builder
+func SetNodeBuilderProps(name string, props []PropSchema) {
+	Global.SetNodeBuilderProps(name, props)
+}
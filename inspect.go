@@ -40,6 +40,21 @@ func (i Inspector) GetNodeBuildersInfo() map[string]NodeBuilderInfo {
 	return infos
 }
 
+// GetNodeBuildersProps return declared props of node builders
+func (i Inspector) GetNodeBuildersProps() map[string][]PropSchema {
+	props := make(map[string][]PropSchema)
+	if i.target != nil {
+		i.target.buildersLocker.RLock()
+		defer i.target.buildersLocker.RUnlock()
+
+		for name, schemas := range i.target.buildersProps {
+			props[name] = append([]PropSchema(nil), schemas...)
+		}
+	}
+
+	return props
+}
+
 func (i Inspector) GetPlansName() []string {
 	var names []string
 	if i.target != nil {
@@ -54,6 +69,58 @@ func (i Inspector) GetPlansName() []string {
 	return names
 }
 
+// GetPlanLoadErrors return the latest errors of plans failed to load from PlanStore
+func (i Inspector) GetPlanLoadErrors() map[string]PlanLoadError {
+	errs := make(map[string]PlanLoadError)
+	if i.target != nil {
+		i.target.loadErrorsLocker.Lock()
+		defer i.target.loadErrorsLocker.Unlock()
+
+		for name, err := range i.target.loadErrors {
+			errs[name] = err
+		}
+	}
+
+	return errs
+}
+
+// PlanTemplateInfo parameters of plan template and plans instantiated from it
+type PlanTemplateInfo struct {
+	Params []PropSchema
+
+	// Instances params of instantiated plans which are still registered, keyed by plan name
+	Instances map[string]map[string]interface{}
+}
+
+// GetPlanTemplates return plan templates register in engine, keyed by template name
+func (i Inspector) GetPlanTemplates() map[string]PlanTemplateInfo {
+	infos := make(map[string]PlanTemplateInfo)
+	if i.target != nil {
+		i.target.templatesLocker.Lock()
+		defer i.target.templatesLocker.Unlock()
+
+		i.target.plansLocker.RLock()
+		defer i.target.plansLocker.RUnlock()
+
+		for name, template := range i.target.templates {
+			info := PlanTemplateInfo{
+				Params:    append([]PropSchema(nil), template.Template.Params...),
+				Instances: make(map[string]map[string]interface{}),
+			}
+
+			for plan, params := range template.Instances {
+				if i.target.plans[plan] != nil {
+					info.Instances[plan] = params
+				}
+			}
+
+			infos[name] = info
+		}
+	}
+
+	return infos
+}
+
 type PlanInfo struct {
 	Version string
 
@@ -64,6 +131,18 @@ type PlanInfo struct {
 	GlobalProps map[string]interface{}
 
 	LabelMap map[string]bool
+
+	// Base name of the inherited plan
+	Base string
+
+	PropsGroups []PropsGroupInfo
+}
+
+// PropsGroupInfo props applied to nodes carrying Label or of node type Type, in declaration order
+type PropsGroupInfo struct {
+	Label, Type string
+
+	Props map[string]interface{}
 }
 
 type VertexInfo struct {
@@ -104,34 +183,50 @@ func (i Inspector) DescribePlan(name string) PlanInfo {
 
 		if plan != nil {
 			plan.locker.RLock()
-			defer plan.locker.RUnlock()
-
-			info.Version = plan.version
-			info.Vertexes = make([]VertexInfo, 0, len(plan.graph.Vertexes))
-			for vName, vertex := range plan.graph.Vertexes {
-				info.Vertexes = append(info.Vertexes, VertexInfo{
-					VertexName: vName,
-					NodeInfo:   describeNode(plan, vertex.RefRoot.NodeName),
-				})
+			info = describePlan(plan)
+			plan.locker.RUnlock()
+		}
+	}
 
-				for _, vNext := range vertex.Next {
-					if vNext != nil && vNext.RefRoot != nil {
-						info.Edges = append(info.Edges, Edge{
-							From: vName,
-							To:   vNext.RefRoot.NodeName,
-						})
-					}
-				}
+	if info.LabelMap == nil {
+		info.LabelMap = make(map[string]bool)
+	}
+
+	return info
+}
+
+// describePlan describe initialized plan, caller should hold the plan lock
+func describePlan(plan *Plan) PlanInfo {
+	var info PlanInfo
+
+	info.Version = plan.version
+	info.Vertexes = make([]VertexInfo, 0, len(plan.graph.Vertexes))
+	for vName, vertex := range plan.graph.Vertexes {
+		info.Vertexes = append(info.Vertexes, VertexInfo{
+			VertexName: vName,
+			NodeInfo:   describeNode(plan, vertex.RefRoot.NodeName),
+		})
+
+		for _, vNext := range vertex.Next {
+			if vNext != nil && vNext.RefRoot != nil {
+				info.Edges = append(info.Edges, Edge{
+					From: vName,
+					To:   vNext.RefRoot.NodeName,
+				})
 			}
+		}
+	}
 
-			info.GlobalProps = map[string]interface{}{}
-			if exportable, ok := plan.props.(ExportableProps); ok {
-				raw := exportable.Raw()
-				for k, v := range raw {
-					if !strings.Contains(k, ".") {
-						info.GlobalProps[k] = v
-					}
-				}
+	info.GlobalProps = map[string]interface{}{}
+	if nested, ok := plan.props.(NestedProps); ok {
+		for k, v := range nested[""] {
+			info.GlobalProps[k] = maskProp(v)
+		}
+	} else if exportable, ok := plan.props.(ExportableProps); ok {
+		raw := exportable.Raw()
+		for k, v := range raw {
+			if !strings.Contains(k, ".") {
+				info.GlobalProps[k] = maskProp(v)
 			}
 		}
 	}
@@ -143,6 +238,16 @@ func (i Inspector) DescribePlan(name string) PlanInfo {
 		}
 	}
 
+	info.Base = plan.Base
+	for _, group := range plan.graph.PropsGroups {
+		groupInfo := PropsGroupInfo{Label: group.Label, Type: group.Type, Props: make(map[string]interface{})}
+		for k, v := range group.Props {
+			groupInfo.Props[k] = maskProp(v)
+		}
+
+		info.PropsGroups = append(info.PropsGroups, groupInfo)
+	}
+
 	return info
 }
 
@@ -163,12 +268,16 @@ func describeNode(plan *Plan, node string, path ...string) NodeInfo {
 	path = append(path, node)
 	prefix := strings.Join(path, ".")
 
-	if exportable, ok := plan.props.(ExportableProps); ok {
+	if nested, ok := plan.props.(NestedProps); ok {
+		for k, v := range nested[prefix] {
+			info.Props[k] = maskProp(v)
+		}
+	} else if exportable, ok := plan.props.(ExportableProps); ok {
 		raw := exportable.Raw()
 		for k, v := range raw {
 			p := strings.LastIndex(k, ".")
 			if p > 0 && p+1 < len(k) && k[:p] == prefix {
-				info.Props[k[p+1:]] = v
+				info.Props[k[p+1:]] = maskProp(v)
 			}
 		}
 	}
@@ -0,0 +1,215 @@
+package running
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	TraceStatusSuccess = "success"
+	TraceStatusFailed  = "failed"
+	TraceStatusSkipped = "skipped"
+)
+
+// NodeTrace execution result of a node
+type NodeTrace struct {
+	Status string
+
+	Duration time.Duration
+}
+
+// ExecTrace execution results keyed by node path, example: NodeA, ClusterA.SubNodeB
+type ExecTrace map[string]NodeTrace
+
+var traceColors = map[string]string{
+	TraceStatusSuccess: "#c8e6c9",
+	TraceStatusFailed:  "#ffcdd2",
+	TraceStatusSkipped: "#eeeeee",
+}
+
+// ToDOT render plan as Graphviz DOT.
+// clusters are drawn as subgraphs, virtual nodes are drawn as dashed ellipses.
+// trace is optional, nodes are filled by status and annotated with duration if set.
+func (info PlanInfo) ToDOT(trace ExecTrace) string {
+	var sb strings.Builder
+
+	sb.WriteString("digraph plan {\n")
+	sb.WriteString("\trankdir=LR;\n")
+	sb.WriteString("\tnode [shape=box];\n")
+
+	for i, vertex := range info.sortedVertexes() {
+		writeDOTNode(&sb, vertex.NodeInfo, vertex.VertexName, "\t", fmt.Sprintf("%d", i), trace)
+	}
+
+	for _, edge := range info.sortedEdges() {
+		sb.WriteString(fmt.Sprintf("\t%s -> %s;\n", quoteDOT(edge.From), quoteDOT(edge.To)))
+	}
+
+	sb.WriteString("}\n")
+	return sb.String()
+}
+
+func writeDOTNode(sb *strings.Builder, node NodeInfo, path, indent, clusterID string, trace ExecTrace) {
+	attrs := []string{"label=" + quoteDOT(nodeLabel(node, path, trace, "\n"))}
+
+	if node.Virtual {
+		attrs = append(attrs, "shape=ellipse", "style=dashed")
+	} else if t, ok := trace[path]; ok && traceColors[t.Status] != "" {
+		attrs = append(attrs, "style=filled", "fillcolor="+quoteDOT(traceColors[t.Status]))
+	}
+
+	if len(node.SubNodes) == 0 {
+		sb.WriteString(fmt.Sprintf("%s%s [%s];\n", indent, quoteDOT(path), strings.Join(attrs, ", ")))
+		return
+	}
+
+	// cluster node is kept as an anchor inside the subgraph, so edges can point to it
+	sb.WriteString(fmt.Sprintf("%ssubgraph cluster_%s {\n", indent, clusterID))
+	sb.WriteString(fmt.Sprintf("%s\tlabel=%s;\n", indent, quoteDOT(path)))
+	sb.WriteString(fmt.Sprintf("%s\tstyle=rounded;\n", indent))
+	sb.WriteString(fmt.Sprintf("%s\t%s [%s];\n", indent, quoteDOT(path), strings.Join(attrs, ", ")))
+
+	for i, sub := range node.SubNodes {
+		writeDOTNode(sb, sub, path+"."+sub.NodeName, indent+"\t", fmt.Sprintf("%s_%d", clusterID, i), trace)
+	}
+
+	sb.WriteString(indent + "}\n")
+}
+
+// ToMermaid render plan as Mermaid flowchart.
+// clusters are drawn as subgraphs, virtual nodes are drawn as dashed circles.
+// trace is optional, nodes are filled by status and annotated with duration if set.
+func (info PlanInfo) ToMermaid(trace ExecTrace) string {
+	var sb strings.Builder
+
+	ids := make(map[string]string)
+	classes := make(map[string][]string)
+
+	sb.WriteString("flowchart LR\n")
+
+	for _, vertex := range info.sortedVertexes() {
+		writeMermaidNode(&sb, vertex.NodeInfo, vertex.VertexName, "\t", ids, classes, trace)
+	}
+
+	for _, edge := range info.sortedEdges() {
+		sb.WriteString(fmt.Sprintf("\t%s --> %s\n", ids[edge.From], ids[edge.To]))
+	}
+
+	classNames := make([]string, 0, len(classes))
+	for class := range classes {
+		classNames = append(classNames, class)
+	}
+	sort.Strings(classNames)
+
+	for _, class := range classNames {
+		if color := traceColors[class]; color != "" {
+			sb.WriteString(fmt.Sprintf("\tclassDef %s fill:%s\n", class, color))
+		} else {
+			sb.WriteString(fmt.Sprintf("\tclassDef %s stroke-dasharray: 5 5\n", class))
+		}
+
+		sb.WriteString(fmt.Sprintf("\tclass %s %s\n", strings.Join(classes[class], ","), class))
+	}
+
+	return sb.String()
+}
+
+func writeMermaidNode(sb *strings.Builder, node NodeInfo, path, indent string,
+	ids map[string]string, classes map[string][]string, trace ExecTrace) {
+	id := fmt.Sprintf("n%d", len(ids))
+	ids[path] = id
+
+	label := strings.ReplaceAll(nodeLabel(node, path, trace, "<br/>"), `"`, "#quot;")
+
+	if node.Virtual {
+		classes["virtual"] = append(classes["virtual"], id)
+	} else if t, ok := trace[path]; ok && traceColors[t.Status] != "" {
+		classes[t.Status] = append(classes[t.Status], id)
+	}
+
+	if len(node.SubNodes) == 0 {
+		if node.Virtual {
+			sb.WriteString(fmt.Sprintf("%s%s((\"%s\"))\n", indent, id, label))
+		} else {
+			sb.WriteString(fmt.Sprintf("%s%s[\"%s\"]\n", indent, id, label))
+		}
+		return
+	}
+
+	// cluster node is kept as an anchor inside the subgraph, so edges can point to it
+	sb.WriteString(fmt.Sprintf("%ssubgraph %s_group[\"%s\"]\n", indent, id, strings.ReplaceAll(path, `"`, "#quot;")))
+	sb.WriteString(fmt.Sprintf("%s\t%s[\"%s\"]\n", indent, id, label))
+
+	for _, sub := range node.SubNodes {
+		writeMermaidNode(sb, sub, path+"."+sub.NodeName, indent+"\t", ids, classes, trace)
+	}
+
+	sb.WriteString(indent + "end\n")
+}
+
+// nodeLabel format: name, type, wrappers, labels, reuse flag and trace, one item per line
+func nodeLabel(node NodeInfo, path string, trace ExecTrace, newline string) string {
+	lines := []string{node.NodeName}
+
+	if node.Virtual {
+		lines = append(lines, "(virtual)")
+	} else if node.NodeType != "" {
+		lines = append(lines, node.NodeType)
+	}
+
+	if len(node.Wrappers) > 0 {
+		lines = append(lines, "wrapped by "+strings.Join(node.Wrappers, ", "))
+	}
+
+	if len(node.LabelMap) > 0 {
+		labels := make([]string, 0, len(node.LabelMap))
+		for label := range node.LabelMap {
+			labels = append(labels, "@"+label)
+		}
+		sort.Strings(labels)
+
+		lines = append(lines, strings.Join(labels, " "))
+	}
+
+	if node.ReUse {
+		lines = append(lines, "(reuse)")
+	}
+
+	if t, ok := trace[path]; ok {
+		if t.Duration > 0 {
+			lines = append(lines, fmt.Sprintf("%s %s", t.Status, t.Duration))
+		} else {
+			lines = append(lines, t.Status)
+		}
+	}
+
+	return strings.Join(lines, newline)
+}
+
+func quoteDOT(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+	s = strings.ReplaceAll(s, "\n", `\n`)
+	return `"` + s + `"`
+}
+
+func (info PlanInfo) sortedVertexes() []VertexInfo {
+	vertexes := make([]VertexInfo, len(info.Vertexes))
+	copy(vertexes, info.Vertexes)
+
+	sort.Slice(vertexes, func(i, j int) bool {
+		return vertexes[i].VertexName < vertexes[j].VertexName
+	})
+
+	return vertexes
+}
+
+func (info PlanInfo) sortedEdges() []Edge {
+	edges := make([]Edge, len(info.Edges))
+	copy(edges, info.Edges)
+
+	sortEdges(edges)
+	return edges
+}
@@ -0,0 +1,275 @@
+package running
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// JsonPatchOp operation of RFC 6902 JSON Patch
+type JsonPatchOp struct {
+	Op string `json:"op"`
+
+	Path string `json:"path"`
+
+	From string `json:"from,omitempty"`
+
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+// ApplyJsonPatch apply RFC 6902 JSON Patch to json document, the document is not changed if any operation failed
+func ApplyJsonPatch(doc []byte, patch []byte) ([]byte, error) {
+	var ops []JsonPatchOp
+	if err := json.Unmarshal(patch, &ops); err != nil {
+		return nil, fmt.Errorf("invalid json patch, %w", err)
+	}
+
+	var root interface{}
+	if err := json.Unmarshal(doc, &root); err != nil {
+		return nil, fmt.Errorf("invalid json document, %w", err)
+	}
+
+	for i, op := range ops {
+		var err error
+		if root, err = applyJsonPatchOp(root, op); err != nil {
+			return nil, fmt.Errorf("json patch operation %d (%s %s) failed, %w", i, op.Op, op.Path, err)
+		}
+	}
+
+	return json.Marshal(root)
+}
+
+func applyJsonPatchOp(root interface{}, op JsonPatchOp) (interface{}, error) {
+	path, err := parseJsonPointer(op.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	var value interface{}
+	switch op.Op {
+	case "add", "replace", "test":
+		if op.Value == nil {
+			return nil, fmt.Errorf("value is required")
+		}
+
+		if err = json.Unmarshal(op.Value, &value); err != nil {
+			return nil, err
+		}
+	}
+
+	switch op.Op {
+	case "add":
+		return jsonPatchAdd(root, path, value)
+	case "remove":
+		root, _, err = jsonPatchRemove(root, path)
+		return root, err
+	case "replace":
+		if root, _, err = jsonPatchRemove(root, path); err != nil {
+			return nil, err
+		}
+		return jsonPatchAdd(root, path, value)
+	case "move", "copy":
+		from, err := parseJsonPointer(op.From)
+		if err != nil {
+			return nil, err
+		}
+
+		if op.Op == "move" {
+			if len(from) < len(path) && strings.HasPrefix(op.Path, op.From+"/") {
+				return nil, fmt.Errorf("can not move value into its child")
+			}
+
+			if root, value, err = jsonPatchRemove(root, from); err != nil {
+				return nil, err
+			}
+		} else {
+			if value, err = jsonPatchGet(root, from); err != nil {
+				return nil, err
+			}
+
+			// deep copy by json, so later operations do not affect the source
+			data, _ := json.Marshal(value)
+			_ = json.Unmarshal(data, &value)
+		}
+
+		return jsonPatchAdd(root, path, value)
+	case "test":
+		actual, err := jsonPatchGet(root, path)
+		if err != nil {
+			return nil, err
+		}
+
+		if !reflect.DeepEqual(actual, value) {
+			return nil, fmt.Errorf("test failed, expect %v, got %v", value, actual)
+		}
+
+		return root, nil
+	default:
+		return nil, fmt.Errorf("unknown operation %q", op.Op)
+	}
+}
+
+// parseJsonPointer parse RFC 6901 JSON Pointer into reference tokens
+func parseJsonPointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return nil, nil
+	}
+
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("invalid json pointer %q", pointer)
+	}
+
+	tokens := strings.Split(pointer[1:], "/")
+	for i, token := range tokens {
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+	}
+
+	return tokens, nil
+}
+
+func jsonPatchGet(node interface{}, path []string) (interface{}, error) {
+	for _, token := range path {
+		switch container := node.(type) {
+		case map[string]interface{}:
+			value, ok := container[token]
+			if !ok {
+				return nil, fmt.Errorf("member %q not found", token)
+			}
+			node = value
+		case []interface{}:
+			i, err := jsonArrayIndex(token, len(container)-1)
+			if err != nil {
+				return nil, err
+			}
+			node = container[i]
+		default:
+			return nil, fmt.Errorf("can not reference %q in %s", token, jsonTypeName(node))
+		}
+	}
+
+	return node, nil
+}
+
+func jsonPatchAdd(node interface{}, path []string, value interface{}) (interface{}, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+
+	token := path[0]
+
+	switch container := node.(type) {
+	case map[string]interface{}:
+		if len(path) == 1 {
+			container[token] = value
+			return container, nil
+		}
+
+		child, ok := container[token]
+		if !ok {
+			return nil, fmt.Errorf("member %q not found", token)
+		}
+
+		child, err := jsonPatchAdd(child, path[1:], value)
+		if err != nil {
+			return nil, err
+		}
+
+		container[token] = child
+		return container, nil
+	case []interface{}:
+		if len(path) == 1 {
+			i := len(container)
+			if token != "-" {
+				var err error
+				if i, err = jsonArrayIndex(token, len(container)); err != nil {
+					return nil, err
+				}
+			}
+
+			container = append(container, nil)
+			copy(container[i+1:], container[i:])
+			container[i] = value
+			return container, nil
+		}
+
+		i, err := jsonArrayIndex(token, len(container)-1)
+		if err != nil {
+			return nil, err
+		}
+
+		child, err := jsonPatchAdd(container[i], path[1:], value)
+		if err != nil {
+			return nil, err
+		}
+
+		container[i] = child
+		return container, nil
+	default:
+		return nil, fmt.Errorf("can not add %q to %s", token, jsonTypeName(node))
+	}
+}
+
+// jsonPatchRemove remove value at path, return the changed node and removed value
+func jsonPatchRemove(node interface{}, path []string) (interface{}, interface{}, error) {
+	if len(path) == 0 {
+		return nil, node, nil
+	}
+
+	token := path[0]
+
+	switch container := node.(type) {
+	case map[string]interface{}:
+		child, ok := container[token]
+		if !ok {
+			return nil, nil, fmt.Errorf("member %q not found", token)
+		}
+
+		if len(path) == 1 {
+			delete(container, token)
+			return container, child, nil
+		}
+
+		child, removed, err := jsonPatchRemove(child, path[1:])
+		if err != nil {
+			return nil, nil, err
+		}
+
+		container[token] = child
+		return container, removed, nil
+	case []interface{}:
+		i, err := jsonArrayIndex(token, len(container)-1)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if len(path) == 1 {
+			removed := container[i]
+			return append(container[:i], container[i+1:]...), removed, nil
+		}
+
+		child, removed, err := jsonPatchRemove(container[i], path[1:])
+		if err != nil {
+			return nil, nil, err
+		}
+
+		container[i] = child
+		return container, removed, nil
+	default:
+		return nil, nil, fmt.Errorf("can not remove %q from %s", token, jsonTypeName(node))
+	}
+}
+
+func jsonArrayIndex(token string, max int) (int, error) {
+	i, err := strconv.Atoi(token)
+	if err != nil || i < 0 || (len(token) > 1 && token[0] == '0') {
+		return 0, fmt.Errorf("invalid array index %q", token)
+	}
+
+	if i > max {
+		return 0, fmt.Errorf("array index %d out of range", i)
+	}
+
+	return i, nil
+}
@@ -18,8 +18,17 @@ type Plan struct {
 
 	Strict bool
 
+	// Base name of plan to inherit, graph and props of base plan register in the same engine
+	// are copied before Options, so Options and Props override them. resolved when register plan.
+	Base string
+
 	version string
 
+	// propsVersion changed when props updated by UpdatePlanProps, workers are reconfigured on next checkout
+	propsVersion int
+
+	inherit Option
+
 	graph *_DAG
 
 	props Props
@@ -50,6 +59,13 @@ func (plan *Plan) Init() error {
 	defer plan.locker.Unlock()
 
 	graph := newDAG()
+	graph.owner = plan
+	if plan.inherit != nil {
+		plan.inherit(graph)
+	} else if plan.Base != "" {
+		graph.Warning = append(graph.Warning, fmt.Sprintf("base plan %s not resolved, register plan to engine", plan.Base))
+	}
+
 	for _, option := range plan.Options {
 		option(graph)
 	}
@@ -57,17 +73,24 @@ func (plan *Plan) Init() error {
 		return fmt.Errorf("invalid plan, %w", err)
 	}
 
+	props, ok := graph.initProps(plan.Props)
+	if !ok {
+		graph.Warning = append(graph.Warning, "props keys of renamed, removed or included nodes not updated, props not exportable")
+	}
+
 	if plan.Strict && len(graph.Warning) > 0 {
 		return fmt.Errorf("invaild plan, %s", strings.Join(graph.Warning, ";"))
 	}
 
 	plan.version = strconv.FormatInt(time.Now().Unix(), 10)
 	plan.graph = graph
-	if plan.Props != nil {
-		plan.props = plan.Props.Copy()
-	} else {
-		plan.props = EmptyProps{}
-	}
+	plan.props = props
+
+	return plan.initPrebuilt()
+}
+
+// initPrebuilt clone prebuilt nodes, nodes cached for reuse are dropped
+func (plan *Plan) initPrebuilt() error {
 	plan.prebuilt = make(map[string]Node)
 
 	for _, node := range plan.Prebuilt {
@@ -84,3 +107,68 @@ func (plan *Plan) Init() error {
 
 	return nil
 }
+
+// initProps copy props and apply props changes of graph, return false if props are not exportable to change
+func (graph *_DAG) initProps(raw Props) (Props, bool) {
+	var props Props = EmptyProps{}
+	if raw != nil {
+		props = raw.Copy()
+	}
+
+	if len(graph.PropsOps) > 0 {
+		if _, ok := props.(EmptyProps); ok {
+			props = StandardProps{}
+		}
+
+		if nested, ok := props.(NestedProps); ok {
+			nested.applyPropsOps(graph.PropsOps)
+		} else if exportable, ok := props.(ExportableProps); ok {
+			applyPropsOps(exportable.Raw(), graph.PropsOps)
+		} else {
+			return props, false
+		}
+	}
+
+	return props, true
+}
+
+// applyPropsOps rename or remove node in node path part of props keys, global props are not changed.
+// example: rename A to B, "A.key" => "B.key", "Cluster.A.key" => "Cluster.B.key".
+// props of included nodes are added only if not set, so local props take precedence.
+func applyPropsOps(raw map[string]interface{}, ops []_PropsOp) {
+	for _, op := range ops {
+		if op.Props != nil {
+			for key, value := range op.Props {
+				if _, ok := raw[key]; !ok {
+					raw[key] = value
+				}
+			}
+			continue
+		}
+
+		renamed := make(map[string]interface{})
+
+		for key, value := range raw {
+			segments := strings.Split(key, ".")
+			matched := false
+
+			for i := 0; i < len(segments)-1; i++ {
+				if segments[i] == op.From {
+					segments[i] = op.To
+					matched = true
+				}
+			}
+
+			if matched {
+				delete(raw, key)
+				if op.To != "" {
+					renamed[strings.Join(segments, ".")] = value
+				}
+			}
+		}
+
+		for key, value := range renamed {
+			raw[key] = value
+		}
+	}
+}
@@ -0,0 +1,451 @@
+package running
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// PlanDiff structured difference between two plans, all fields are sorted
+type PlanDiff struct {
+	AddedVertexes []string
+
+	RemovedVertexes []string
+
+	AddedEdges []Edge
+
+	RemovedEdges []Edge
+
+	// Nodes changes of nodes keyed by node path, example: ClusterA.SubNodeB
+	Nodes []NodeDiff
+
+	GlobalProps []PropDiff
+
+	OldBase, NewBase string
+
+	// PropsGroups changes of props groups, props of groups with the same label or type are merged in declaration order
+	PropsGroups []PropsGroupDiff
+}
+
+// PropsGroupDiff difference of props applied by label or node type
+type PropsGroupDiff struct {
+	Label, Type string
+
+	Props []PropDiff
+}
+
+// NodeDiff difference of a node, only the changed fields are set
+type NodeDiff struct {
+	Path string
+
+	// Added or Removed node only have type and props set
+	Added, Removed bool
+
+	OldType, NewType string
+
+	OldWrappers, NewWrappers []string
+
+	AddedLabels, RemovedLabels []string
+
+	OldReUse, NewReUse bool
+
+	Props []PropDiff
+}
+
+// PropDiff difference of a prop, Old is nil if the prop is added, New is nil if the prop is removed
+type PropDiff struct {
+	Key string
+
+	Old, New interface{}
+}
+
+// Empty return true if there is no difference
+func (diff PlanDiff) Empty() bool {
+	return len(diff.AddedVertexes) == 0 && len(diff.RemovedVertexes) == 0 &&
+		len(diff.AddedEdges) == 0 && len(diff.RemovedEdges) == 0 &&
+		len(diff.Nodes) == 0 && len(diff.GlobalProps) == 0 &&
+		diff.OldBase == diff.NewBase && len(diff.PropsGroups) == 0
+}
+
+// String format difference line by line, "+" for added, "-" for removed and "~" for changed
+func (diff PlanDiff) String() string {
+	var lines []string
+
+	for _, v := range diff.AddedVertexes {
+		lines = append(lines, "+ vertex "+v)
+	}
+
+	for _, v := range diff.RemovedVertexes {
+		lines = append(lines, "- vertex "+v)
+	}
+
+	for _, e := range diff.AddedEdges {
+		lines = append(lines, fmt.Sprintf("+ edge %s -> %s", e.From, e.To))
+	}
+
+	for _, e := range diff.RemovedEdges {
+		lines = append(lines, fmt.Sprintf("- edge %s -> %s", e.From, e.To))
+	}
+
+	if diff.OldBase != diff.NewBase {
+		lines = append(lines, fmt.Sprintf("~ base: %s -> %s", diff.OldBase, diff.NewBase))
+	}
+
+	for _, p := range diff.GlobalProps {
+		lines = append(lines, p.format("global props"))
+	}
+
+	for _, g := range diff.PropsGroups {
+		subject := "props group type " + g.Type
+		if g.Label != "" {
+			subject = "props group label " + g.Label
+		}
+
+		for _, p := range g.Props {
+			lines = append(lines, p.format(subject))
+		}
+	}
+
+	for _, n := range diff.Nodes {
+		switch {
+		case n.Added:
+			lines = append(lines, fmt.Sprintf("+ node %s: %s", n.Path, n.NewType))
+			continue
+		case n.Removed:
+			lines = append(lines, fmt.Sprintf("- node %s: %s", n.Path, n.OldType))
+			continue
+		}
+
+		if n.OldType != n.NewType {
+			lines = append(lines, fmt.Sprintf("~ node %s type: %s -> %s", n.Path, n.OldType, n.NewType))
+		}
+
+		if !reflect.DeepEqual(n.OldWrappers, n.NewWrappers) {
+			lines = append(lines, fmt.Sprintf("~ node %s wrappers: %v -> %v", n.Path, n.OldWrappers, n.NewWrappers))
+		}
+
+		for _, label := range n.AddedLabels {
+			lines = append(lines, fmt.Sprintf("+ node %s label: %s", n.Path, label))
+		}
+
+		for _, label := range n.RemovedLabels {
+			lines = append(lines, fmt.Sprintf("- node %s label: %s", n.Path, label))
+		}
+
+		if n.OldReUse != n.NewReUse {
+			lines = append(lines, fmt.Sprintf("~ node %s reuse: %t -> %t", n.Path, n.OldReUse, n.NewReUse))
+		}
+
+		for _, p := range n.Props {
+			lines = append(lines, p.format("node "+n.Path+" props"))
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+func (diff PropDiff) format(subject string) string {
+	switch {
+	case diff.Old == nil:
+		return fmt.Sprintf("+ %s %s: %v", subject, diff.Key, diff.New)
+	case diff.New == nil:
+		return fmt.Sprintf("- %s %s: %v", subject, diff.Key, diff.Old)
+	default:
+		return fmt.Sprintf("~ %s %s: %v -> %v", subject, diff.Key, diff.Old, diff.New)
+	}
+}
+
+// DiffPlans compare plan a with plan b, uninitialized plan will be initialized
+func DiffPlans(a, b *Plan) (PlanDiff, error) {
+	infoA, err := describePlanForDiff(a)
+	if err != nil {
+		return PlanDiff{}, err
+	}
+
+	infoB, err := describePlanForDiff(b)
+	if err != nil {
+		return PlanDiff{}, err
+	}
+
+	return diffPlanInfos(infoA, infoB), nil
+}
+
+// DiffPlan compare plan register in engine with plan in json format
+func (i Inspector) DiffPlan(name string, jsonData []byte) (PlanDiff, error) {
+	if i.target == nil {
+		return PlanDiff{}, ErrPlanNotFound
+	}
+
+	i.target.plansLocker.RLock()
+	plan := i.target.plans[name]
+	i.target.plansLocker.RUnlock()
+
+	if plan == nil {
+		return PlanDiff{}, fmt.Errorf("%w, plan: %s", ErrPlanNotFound, name)
+	}
+
+	other := &Plan{}
+	if err := json.Unmarshal(jsonData, other); err != nil {
+		return PlanDiff{}, err
+	}
+
+	return DiffPlans(plan, other)
+}
+
+func describePlanForDiff(plan *Plan) (PlanInfo, error) {
+	plan.locker.RLock()
+	initialized := plan.graph != nil
+	plan.locker.RUnlock()
+
+	if !initialized {
+		if err := plan.Init(); err != nil {
+			return PlanInfo{}, err
+		}
+	}
+
+	plan.locker.RLock()
+	defer plan.locker.RUnlock()
+
+	return describePlan(plan), nil
+}
+
+func diffPlanInfos(a, b PlanInfo) PlanDiff {
+	var diff PlanDiff
+
+	vertexesA, vertexesB := make(map[string]bool), make(map[string]bool)
+	for _, v := range a.Vertexes {
+		vertexesA[v.VertexName] = true
+	}
+	for _, v := range b.Vertexes {
+		vertexesB[v.VertexName] = true
+		if !vertexesA[v.VertexName] {
+			diff.AddedVertexes = append(diff.AddedVertexes, v.VertexName)
+		}
+	}
+	for _, v := range a.Vertexes {
+		if !vertexesB[v.VertexName] {
+			diff.RemovedVertexes = append(diff.RemovedVertexes, v.VertexName)
+		}
+	}
+	sort.Strings(diff.AddedVertexes)
+	sort.Strings(diff.RemovedVertexes)
+
+	edgesA, edgesB := make(map[Edge]int), make(map[Edge]int)
+	for _, e := range a.Edges {
+		edgesA[e]++
+	}
+	for _, e := range b.Edges {
+		edgesB[e]++
+	}
+	for e, n := range edgesB {
+		for i := edgesA[e]; i < n; i++ {
+			diff.AddedEdges = append(diff.AddedEdges, e)
+		}
+	}
+	for e, n := range edgesA {
+		for i := edgesB[e]; i < n; i++ {
+			diff.RemovedEdges = append(diff.RemovedEdges, e)
+		}
+	}
+	sortEdges(diff.AddedEdges)
+	sortEdges(diff.RemovedEdges)
+
+	diff.GlobalProps = diffProps(a.GlobalProps, b.GlobalProps)
+	diff.OldBase, diff.NewBase = a.Base, b.Base
+	diff.PropsGroups = diffPropsGroups(a.PropsGroups, b.PropsGroups)
+
+	nodesA, nodesB := flattenNodes(a), flattenNodes(b)
+	paths := make(map[string]bool)
+	for path := range nodesA {
+		paths[path] = true
+	}
+	for path := range nodesB {
+		paths[path] = true
+	}
+
+	sortedPaths := make([]string, 0, len(paths))
+	for path := range paths {
+		sortedPaths = append(sortedPaths, path)
+	}
+	sort.Strings(sortedPaths)
+
+	for _, path := range sortedPaths {
+		nodeA, inA := nodesA[path]
+		nodeB, inB := nodesB[path]
+
+		nodeDiff := NodeDiff{Path: path}
+
+		switch {
+		case !inA:
+			nodeDiff.Added = true
+			nodeDiff.NewType = nodeB.NodeType
+			nodeDiff.Props = diffProps(nil, nodeB.Props)
+		case !inB:
+			nodeDiff.Removed = true
+			nodeDiff.OldType = nodeA.NodeType
+			nodeDiff.Props = diffProps(nodeA.Props, nil)
+		default:
+			changed := false
+
+			if nodeA.NodeType != nodeB.NodeType {
+				nodeDiff.OldType, nodeDiff.NewType = nodeA.NodeType, nodeB.NodeType
+				changed = true
+			}
+
+			if len(nodeA.Wrappers) != 0 || len(nodeB.Wrappers) != 0 {
+				if !reflect.DeepEqual(nodeA.Wrappers, nodeB.Wrappers) {
+					nodeDiff.OldWrappers, nodeDiff.NewWrappers = nodeA.Wrappers, nodeB.Wrappers
+					changed = true
+				}
+			}
+
+			for label := range nodeB.LabelMap {
+				if _, ok := nodeA.LabelMap[label]; !ok {
+					nodeDiff.AddedLabels = append(nodeDiff.AddedLabels, label)
+					changed = true
+				}
+			}
+			for label := range nodeA.LabelMap {
+				if _, ok := nodeB.LabelMap[label]; !ok {
+					nodeDiff.RemovedLabels = append(nodeDiff.RemovedLabels, label)
+					changed = true
+				}
+			}
+			sort.Strings(nodeDiff.AddedLabels)
+			sort.Strings(nodeDiff.RemovedLabels)
+
+			if nodeA.ReUse != nodeB.ReUse {
+				nodeDiff.OldReUse, nodeDiff.NewReUse = nodeA.ReUse, nodeB.ReUse
+				changed = true
+			}
+
+			if nodeDiff.Props = diffProps(nodeA.Props, nodeB.Props); len(nodeDiff.Props) > 0 {
+				changed = true
+			}
+
+			if !changed {
+				continue
+			}
+		}
+
+		diff.Nodes = append(diff.Nodes, nodeDiff)
+	}
+
+	return diff
+}
+
+// diffPropsGroups compare merged props of groups with the same label or type
+func diffPropsGroups(a, b []PropsGroupInfo) []PropsGroupDiff {
+	type groupKey struct {
+		Label, Type string
+	}
+
+	merge := func(groups []PropsGroupInfo) map[groupKey]map[string]interface{} {
+		merged := make(map[groupKey]map[string]interface{})
+		for _, group := range groups {
+			key := groupKey{Label: group.Label, Type: group.Type}
+			if merged[key] == nil {
+				merged[key] = make(map[string]interface{})
+			}
+
+			for k, v := range group.Props {
+				merged[key][k] = v
+			}
+		}
+		return merged
+	}
+
+	groupsA, groupsB := merge(a), merge(b)
+
+	var keys []groupKey
+	for key := range groupsA {
+		keys = append(keys, key)
+	}
+	for key := range groupsB {
+		if _, ok := groupsA[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].Label != keys[j].Label {
+			return keys[i].Label < keys[j].Label
+		}
+		return keys[i].Type < keys[j].Type
+	})
+
+	var diffs []PropsGroupDiff
+	for _, key := range keys {
+		if props := diffProps(groupsA[key], groupsB[key]); len(props) > 0 {
+			diffs = append(diffs, PropsGroupDiff{Label: key.Label, Type: key.Type, Props: props})
+		}
+	}
+
+	return diffs
+}
+
+// flattenNodes collect vertexes and their sub-nodes keyed by node path
+func flattenNodes(info PlanInfo) map[string]NodeInfo {
+	nodes := make(map[string]NodeInfo)
+
+	var walk func(node NodeInfo, path string)
+	walk = func(node NodeInfo, path string) {
+		nodes[path] = node
+
+		for _, sub := range node.SubNodes {
+			walk(sub, path+"."+sub.NodeName)
+		}
+	}
+
+	for _, v := range info.Vertexes {
+		walk(v.NodeInfo, v.VertexName)
+	}
+
+	return nodes
+}
+
+func diffProps(a, b map[string]interface{}) []PropDiff {
+	var diffs []PropDiff
+
+	for k, v := range b {
+		if old, ok := a[k]; !ok {
+			diffs = append(diffs, PropDiff{Key: k, New: v})
+		} else if !propValueEqual(old, v) {
+			diffs = append(diffs, PropDiff{Key: k, Old: old, New: v})
+		}
+	}
+
+	for k, v := range a {
+		if _, ok := b[k]; !ok {
+			diffs = append(diffs, PropDiff{Key: k, Old: v})
+		}
+	}
+
+	sort.Slice(diffs, func(i, j int) bool {
+		return diffs[i].Key < diffs[j].Key
+	})
+
+	return diffs
+}
+
+// propValueEqual compare values by json encoding when they are not deep equal,
+// so values loaded from json are equal to the original ones, example: int 1 and float64 1
+func propValueEqual(a, b interface{}) bool {
+	if reflect.DeepEqual(a, b) {
+		return true
+	}
+
+	dataA, errA := json.Marshal(a)
+	dataB, errB := json.Marshal(b)
+	return errA == nil && errB == nil && string(dataA) == string(dataB)
+}
+
+func sortEdges(edges []Edge) {
+	sort.Slice(edges, func(i, j int) bool {
+		if edges[i].From != edges[j].From {
+			return edges[i].From < edges[j].From
+		}
+		return edges[i].To < edges[j].To
+	})
+}
@@ -0,0 +1,823 @@
+package running
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Plan DSL is a line based text format of plan options, example:
+//
+//	# declare nodes without linking them, used as sub-nodes
+//	node B1:BaseTest, B2:BaseTest
+//	virtual V
+//
+//	# declare nodes inline and link them, A -> B, A -> C, B -> D, C -> D
+//	A:TypeA @label -> B:TypeB, C:Loop{B1, B2} -> D:TypeD
+//
+//	wrap Debug(A, B)
+//	wrap Timer(*)
+//	reuse A, D
+//	props @label {"timeout": 200}
+//	props TypeA {"retry": 3}
+//
+// statements:
+//
+//	node    declare nodes, same as AddNodes, MergeNodes and MarkNodes
+//	virtual declare virtual nodes, same as AddVirtualNodes
+//	wrap    wrap nodes, same as WrapNodes, * means WrapAllNodes
+//	reuse   same as ReUseNodes
+//	props   props of nodes carrying @label or of node type, same as PropsForLabel and PropsForType, props are a json object
+//	chain   nodes separated by "," form a group, each node of a group is linked to each node of the next group.
+//
+// node spec: Name[:Type][{SubNode, ...}][@label ...], type is required when the node is first declared,
+// declare the node again with another type is an error.
+// identifiers contain characters other than letters, digits, "_" and "." are double-quoted, example: "my-node":Type.
+// statements are separated by newline or ";", a line ending with "->", "," or an open bracket continues on the next line.
+// "#" and "//" start a comment until end of line.
+
+// DSLError syntax error of plan DSL, with position of the error
+type DSLError struct {
+	Line int
+
+	Column int
+
+	Msg string
+}
+
+func (err *DSLError) Error() string {
+	return fmt.Sprintf("plan dsl: line %d, column %d: %s", err.Line, err.Column, err.Msg)
+}
+
+// ParsePlanDSL parse plan DSL into options
+func ParsePlanDSL(src string) ([]Option, error) {
+	tokens, err := lexPlanDSL(src)
+	if err != nil {
+		return nil, err
+	}
+
+	parser := &_DSLParser{tokens: tokens}
+	if err = parser.parse(); err != nil {
+		return nil, err
+	}
+
+	return parser.options, nil
+}
+
+// NewPlanFromDSL similar to NewPlan, but options are parsed from plan DSL
+func NewPlanFromDSL(props Props, prebuilt []Node, src string) (*Plan, error) {
+	options, err := ParsePlanDSL(src)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewPlan(props, prebuilt, options...), nil
+}
+
+// PrintPlanDSL print plan as plan DSL, props are not included except props groups, secrets in them are masked
+func PrintPlanDSL(plan *Plan) (string, error) {
+	plan.locker.RLock()
+	initialized := plan.graph != nil
+	plan.locker.RUnlock()
+
+	if !initialized {
+		if err := plan.Init(); err != nil {
+			return "", err
+		}
+	}
+
+	plan.locker.RLock()
+	defer plan.locker.RUnlock()
+
+	return printDAG(plan.graph)
+}
+
+// ExportPlanDSL export plan register in engine as plan DSL
+func (engine *Engine) ExportPlanDSL(name string) (string, error) {
+	engine.plansLocker.RLock()
+	plan := engine.plans[name]
+	engine.plansLocker.RUnlock()
+
+	if plan == nil {
+		return "", fmt.Errorf("plan: %s not found", name)
+	}
+
+	return PrintPlanDSL(plan)
+}
+
+const (
+	_TokenEOF = iota
+	_TokenNewline
+	_TokenIdent
+	_TokenColon
+	_TokenArrow
+	_TokenComma
+	_TokenSemi
+	_TokenLBrace
+	_TokenRBrace
+	_TokenLParen
+	_TokenRParen
+	_TokenAt
+	_TokenStar
+	_TokenObject
+)
+
+var dslTokenNames = map[int]string{
+	_TokenEOF:     "end of input",
+	_TokenNewline: "newline",
+	_TokenIdent:   "identifier",
+	_TokenColon:   `":"`,
+	_TokenArrow:   `"->"`,
+	_TokenComma:   `","`,
+	_TokenSemi:    `";"`,
+	_TokenLBrace:  `"{"`,
+	_TokenRBrace:  `"}"`,
+	_TokenLParen:  `"("`,
+	_TokenRParen:  `")"`,
+	_TokenAt:      `"@"`,
+	_TokenStar:    `"*"`,
+	_TokenObject:  "json object",
+}
+
+type _DSLToken struct {
+	Kind int
+
+	Text string
+
+	// Quoted identifier is never a keyword
+	Quoted bool
+
+	Line, Column int
+}
+
+func (token _DSLToken) String() string {
+	if token.Kind == _TokenIdent {
+		return fmt.Sprintf("identifier %q", token.Text)
+	}
+
+	return dslTokenNames[token.Kind]
+}
+
+func isDSLIdentRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' || r == '.'
+}
+
+// quoteDSLIdent quote identifier if it can not be lexed as is
+func quoteDSLIdent(ident string) string {
+	if ident == "" || strings.IndexFunc(ident, func(r rune) bool { return !isDSLIdentRune(r) }) >= 0 {
+		return strconv.Quote(ident)
+	}
+
+	return ident
+}
+
+func lexPlanDSL(src string) ([]_DSLToken, error) {
+	var tokens []_DSLToken
+
+	runes := []rune(src)
+	line, column := 1, 1
+
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		token := _DSLToken{Line: line, Column: column}
+		size := 1
+
+		switch {
+		case r == '\n':
+			token.Kind = _TokenNewline
+		case r == ' ' || r == '\t' || r == '\r':
+			i++
+			column++
+			continue
+		case r == '#' || (r == '/' && i+1 < len(runes) && runes[i+1] == '/'):
+			for i < len(runes) && runes[i] != '\n' {
+				i++
+				column++
+			}
+			continue
+		case r == '-' && i+1 < len(runes) && runes[i+1] == '>':
+			token.Kind = _TokenArrow
+			size = 2
+		case r == ':':
+			token.Kind = _TokenColon
+		case r == ',':
+			token.Kind = _TokenComma
+		case r == ';':
+			token.Kind = _TokenSemi
+		case r == '{' && isPropsStatement(tokens):
+			rest := string(runes[i:])
+
+			var raw json.RawMessage
+			decoder := json.NewDecoder(strings.NewReader(rest))
+			if err := decoder.Decode(&raw); err != nil {
+				return nil, &DSLError{Line: line, Column: column, Msg: fmt.Sprintf("invalid json object, %v", err)}
+			}
+
+			text := rest[:decoder.InputOffset()]
+			token.Kind, token.Text = _TokenObject, text
+			tokens = append(tokens, token)
+
+			i += utf8.RuneCountInString(text)
+			if n := strings.Count(text, "\n"); n > 0 {
+				line += n
+				column = utf8.RuneCountInString(text[strings.LastIndex(text, "\n")+1:]) + 1
+			} else {
+				column += utf8.RuneCountInString(text)
+			}
+			continue
+		case r == '{':
+			token.Kind = _TokenLBrace
+		case r == '}':
+			token.Kind = _TokenRBrace
+		case r == '(':
+			token.Kind = _TokenLParen
+		case r == ')':
+			token.Kind = _TokenRParen
+		case r == '@':
+			token.Kind = _TokenAt
+		case r == '*':
+			token.Kind = _TokenStar
+		case r == '"':
+			size = 1
+			for i+size < len(runes) && runes[i+size] != '"' && runes[i+size] != '\n' {
+				if runes[i+size] == '\\' {
+					size++
+				}
+				size++
+			}
+
+			if i+size >= len(runes) || runes[i+size] != '"' {
+				return nil, &DSLError{Line: line, Column: column, Msg: "unterminated quoted identifier"}
+			}
+			size++
+
+			text, err := strconv.Unquote(string(runes[i : i+size]))
+			if err != nil {
+				return nil, &DSLError{Line: line, Column: column, Msg: fmt.Sprintf("invalid quoted identifier, %v", err)}
+			}
+
+			token.Kind, token.Text, token.Quoted = _TokenIdent, text, true
+			tokens = append(tokens, token)
+			i += size
+			column += size
+			continue
+		case isDSLIdentRune(r):
+			token.Kind = _TokenIdent
+			size = 0
+			for i+size < len(runes) && isDSLIdentRune(runes[i+size]) {
+				size++
+			}
+		default:
+			return nil, &DSLError{Line: line, Column: column, Msg: fmt.Sprintf("unexpected character %q", r)}
+		}
+
+		token.Text = string(runes[i : i+size])
+		tokens = append(tokens, token)
+
+		i += size
+		if r == '\n' {
+			line++
+			column = 1
+		} else {
+			column += size
+		}
+	}
+
+	tokens = append(tokens, _DSLToken{Kind: _TokenEOF, Line: line, Column: column})
+	return tokens, nil
+}
+
+// isPropsStatement check if tokens end with `props @label` or `props Type` at start of statement, so "{" starts a json object
+func isPropsStatement(tokens []_DSLToken) bool {
+	n := len(tokens)
+	if n < 2 || tokens[n-1].Kind != _TokenIdent {
+		return false
+	}
+
+	keyword := n - 2
+	if tokens[keyword].Kind == _TokenAt {
+		keyword--
+	}
+
+	if keyword < 0 || tokens[keyword].Kind != _TokenIdent || tokens[keyword].Quoted || tokens[keyword].Text != "props" {
+		return false
+	}
+
+	return keyword == 0 || tokens[keyword-1].Kind == _TokenNewline || tokens[keyword-1].Kind == _TokenSemi
+}
+
+type _DSLParser struct {
+	tokens []_DSLToken
+
+	pos int
+
+	options []Option
+
+	// types of declared nodes, to report conflicting declarations
+	types map[string]string
+
+	virtual map[string]bool
+}
+
+// _DSLNodeSpec node spec parsed from Name[:Type][{SubNode, ...}][@label ...]
+type _DSLNodeSpec struct {
+	Name, Type string
+
+	SubNodes []*_DSLNodeSpec
+
+	Labels []string
+
+	Token _DSLToken
+}
+
+func (parser *_DSLParser) peek() _DSLToken {
+	return parser.tokens[parser.pos]
+}
+
+func (parser *_DSLParser) next() _DSLToken {
+	token := parser.tokens[parser.pos]
+	if token.Kind != _TokenEOF {
+		parser.pos++
+	}
+	return token
+}
+
+func (parser *_DSLParser) skipNewlines() {
+	for parser.peek().Kind == _TokenNewline {
+		parser.next()
+	}
+}
+
+func (parser *_DSLParser) expect(kind int) (_DSLToken, error) {
+	token := parser.next()
+	if token.Kind != kind {
+		return token, parser.errorf(token, "expect %s, got %s", dslTokenNames[kind], token)
+	}
+
+	return token, nil
+}
+
+func (parser *_DSLParser) errorf(token _DSLToken, format string, args ...interface{}) error {
+	return &DSLError{Line: token.Line, Column: token.Column, Msg: fmt.Sprintf(format, args...)}
+}
+
+func (parser *_DSLParser) parse() error {
+	for {
+		for parser.peek().Kind == _TokenNewline || parser.peek().Kind == _TokenSemi {
+			parser.next()
+		}
+
+		if parser.peek().Kind == _TokenEOF {
+			return nil
+		}
+
+		if err := parser.parseStatement(); err != nil {
+			return err
+		}
+
+		switch token := parser.next(); token.Kind {
+		case _TokenNewline, _TokenSemi, _TokenEOF:
+		default:
+			return parser.errorf(token, "expect end of statement, got %s", token)
+		}
+	}
+}
+
+func (parser *_DSLParser) parseStatement() error {
+	token := parser.peek()
+
+	// keywords are only recognized when followed by what they expect,
+	// so nodes can still be named "node", "wrap" and so on
+	if token.Kind == _TokenIdent && !token.Quoted && token.Text == "props" &&
+		(parser.tokens[parser.pos+1].Kind == _TokenAt || parser.tokens[parser.pos+1].Kind == _TokenIdent) {
+		parser.next()
+		return parser.parsePropsStatement()
+	}
+
+	if token.Kind == _TokenIdent && !token.Quoted && parser.tokens[parser.pos+1].Kind == _TokenIdent {
+		switch token.Text {
+		case "node":
+			parser.next()
+			return parser.parseNodeStatement()
+		case "virtual":
+			parser.next()
+			return parser.parseVirtualStatement()
+		case "wrap":
+			parser.next()
+			return parser.parseWrapStatement()
+		case "reuse":
+			parser.next()
+			return parser.parseReUseStatement()
+		}
+	}
+
+	return parser.parseChain()
+}
+
+func (parser *_DSLParser) parseNodeStatement() error {
+	specs, err := parser.parseSpecList()
+	if err != nil {
+		return err
+	}
+
+	for _, spec := range specs {
+		if spec.Type == "" {
+			return parser.errorf(spec.Token, "node %s is declared without type", spec.Name)
+		}
+	}
+
+	return parser.declare(specs)
+}
+
+func (parser *_DSLParser) parseVirtualStatement() error {
+	specs, err := parser.parseSpecList()
+	if err != nil {
+		return err
+	}
+
+	var names []string
+	for _, spec := range specs {
+		if spec.Type != "" {
+			return parser.errorf(spec.Token, "virtual node %s can not have type", spec.Name)
+		}
+
+		if typ := parser.types[spec.Name]; typ != "" {
+			return parser.errorf(spec.Token, "node %s is declared as %s, can not be virtual", spec.Name, typ)
+		}
+
+		names = append(names, spec.Name)
+	}
+
+	parser.options = append(parser.options, AddVirtualNodes(names...))
+	if err = parser.declare(specs); err != nil {
+		return err
+	}
+
+	for _, name := range names {
+		parser.virtual[name] = true
+	}
+
+	return nil
+}
+
+func (parser *_DSLParser) parseWrapStatement() error {
+	wrapper, err := parser.expect(_TokenIdent)
+	if err != nil {
+		return err
+	}
+
+	if _, err = parser.expect(_TokenLParen); err != nil {
+		return err
+	}
+	parser.skipNewlines()
+
+	if parser.peek().Kind == _TokenStar {
+		parser.next()
+		parser.options = append(parser.options, WrapAllNodes(wrapper.Text))
+	} else {
+		targets, err := parser.parseNameList()
+		if err != nil {
+			return err
+		}
+
+		parser.options = append(parser.options, WrapNodes(wrapper.Text, targets...))
+	}
+
+	parser.skipNewlines()
+	_, err = parser.expect(_TokenRParen)
+	return err
+}
+
+func (parser *_DSLParser) parseReUseStatement() error {
+	names, err := parser.parseNameList()
+	if err != nil {
+		return err
+	}
+
+	parser.options = append(parser.options, ReUseNodes(names...))
+	return nil
+}
+
+func (parser *_DSLParser) parsePropsStatement() error {
+	isLabel := parser.peek().Kind == _TokenAt
+	if isLabel {
+		parser.next()
+	}
+
+	target, err := parser.expect(_TokenIdent)
+	if err != nil {
+		return err
+	}
+
+	token, err := parser.expect(_TokenObject)
+	if err != nil {
+		return err
+	}
+
+	var props map[string]interface{}
+	if err = json.Unmarshal([]byte(token.Text), &props); err != nil || props == nil {
+		return parser.errorf(token, "props of %s should be a json object", target.Text)
+	}
+
+	if isLabel {
+		parser.options = append(parser.options, PropsForLabel(target.Text, props))
+	} else {
+		parser.options = append(parser.options, PropsForType(target.Text, props))
+	}
+
+	return nil
+}
+
+func (parser *_DSLParser) parseChain() error {
+	var groups [][]*_DSLNodeSpec
+
+	for {
+		group, err := parser.parseSpecList()
+		if err != nil {
+			return err
+		}
+
+		groups = append(groups, group)
+
+		if parser.peek().Kind != _TokenArrow {
+			break
+		}
+
+		parser.next()
+		parser.skipNewlines()
+	}
+
+	for _, group := range groups {
+		if err := parser.declare(group); err != nil {
+			return err
+		}
+	}
+
+	for i, group := range groups {
+		for _, spec := range group {
+			if i == len(groups)-1 {
+				parser.options = append(parser.options, LinkNodes(spec.Name))
+				continue
+			}
+
+			nodes := []string{spec.Name}
+			for _, next := range groups[i+1] {
+				nodes = append(nodes, next.Name)
+			}
+
+			parser.options = append(parser.options, LinkNodes(nodes...))
+		}
+	}
+
+	return nil
+}
+
+// declare append options to add typed nodes, merge sub-nodes and mark labels, sub-nodes first.
+// return error if node is declared again with another type.
+func (parser *_DSLParser) declare(specs []*_DSLNodeSpec) error {
+	if parser.types == nil {
+		parser.types = make(map[string]string)
+		parser.virtual = make(map[string]bool)
+	}
+
+	for _, spec := range specs {
+		if err := parser.declare(spec.SubNodes); err != nil {
+			return err
+		}
+
+		if spec.Type != "" {
+			if parser.virtual[spec.Name] {
+				return parser.errorf(spec.Token, "virtual node %s can not have type", spec.Name)
+			}
+
+			if typ := parser.types[spec.Name]; typ != "" && typ != spec.Type {
+				return parser.errorf(spec.Token, "node %s is declared as %s, can not be declared again as %s", spec.Name, typ, spec.Type)
+			}
+
+			parser.types[spec.Name] = spec.Type
+			parser.options = append(parser.options, AddNodes(spec.Type, spec.Name))
+		}
+
+		if len(spec.SubNodes) > 0 {
+			var subNodes []string
+			for _, sub := range spec.SubNodes {
+				subNodes = append(subNodes, sub.Name)
+			}
+
+			parser.options = append(parser.options, MergeNodes(spec.Name, subNodes...))
+		}
+
+		for _, label := range spec.Labels {
+			parser.options = append(parser.options, MarkNodes(label, spec.Name))
+		}
+	}
+
+	return nil
+}
+
+func (parser *_DSLParser) parseSpecList() ([]*_DSLNodeSpec, error) {
+	var specs []*_DSLNodeSpec
+
+	for {
+		spec, err := parser.parseSpec()
+		if err != nil {
+			return nil, err
+		}
+
+		specs = append(specs, spec)
+
+		if parser.peek().Kind != _TokenComma {
+			return specs, nil
+		}
+
+		parser.next()
+		parser.skipNewlines()
+	}
+}
+
+func (parser *_DSLParser) parseSpec() (*_DSLNodeSpec, error) {
+	name, err := parser.expect(_TokenIdent)
+	if err != nil {
+		return nil, err
+	}
+
+	spec := &_DSLNodeSpec{Name: name.Text, Token: name}
+
+	if parser.peek().Kind == _TokenColon {
+		parser.next()
+
+		typ, err := parser.expect(_TokenIdent)
+		if err != nil {
+			return nil, err
+		}
+
+		spec.Type = typ.Text
+	}
+
+	if parser.peek().Kind == _TokenLBrace {
+		parser.next()
+		parser.skipNewlines()
+
+		if spec.SubNodes, err = parser.parseSpecList(); err != nil {
+			return nil, err
+		}
+
+		parser.skipNewlines()
+		if _, err = parser.expect(_TokenRBrace); err != nil {
+			return nil, err
+		}
+	}
+
+	for parser.peek().Kind == _TokenAt {
+		parser.next()
+
+		label, err := parser.expect(_TokenIdent)
+		if err != nil {
+			return nil, err
+		}
+
+		spec.Labels = append(spec.Labels, label.Text)
+	}
+
+	return spec, nil
+}
+
+func (parser *_DSLParser) parseNameList() ([]string, error) {
+	var names []string
+
+	for {
+		name, err := parser.expect(_TokenIdent)
+		if err != nil {
+			return nil, err
+		}
+
+		names = append(names, name.Text)
+
+		if parser.peek().Kind != _TokenComma {
+			return names, nil
+		}
+
+		parser.next()
+		parser.skipNewlines()
+	}
+}
+
+// printDAG print graph as plan DSL.
+// all node refs are declared first, sub-nodes before clusters, then wrappers, reuse flags and links.
+func printDAG(graph *_DAG) (string, error) {
+	var sb strings.Builder
+
+	names := make([]string, 0, len(graph.NodeRefs))
+	for name := range graph.NodeRefs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	printed := make(map[string]bool)
+	var printRef func(ref *_NodeRef)
+	printRef = func(ref *_NodeRef) {
+		if printed[ref.NodeName] {
+			return
+		}
+		printed[ref.NodeName] = true
+
+		for _, sub := range ref.SubRefs {
+			printRef(sub)
+		}
+
+		if ref.Virtual {
+			sb.WriteString("virtual ")
+			sb.WriteString(quoteDSLIdent(ref.NodeName))
+		} else {
+			sb.WriteString("node ")
+			sb.WriteString(quoteDSLIdent(ref.NodeName))
+			sb.WriteString(":")
+			sb.WriteString(quoteDSLIdent(ref.NodeType))
+		}
+
+		if len(ref.SubRefs) > 0 {
+			var subs []string
+			for _, sub := range ref.SubRefs {
+				subs = append(subs, quoteDSLIdent(sub.NodeName))
+			}
+
+			sb.WriteString("{" + strings.Join(subs, ", ") + "}")
+		}
+
+		var labels []string
+		for label := range ref.Labels {
+			labels = append(labels, label)
+		}
+		sort.Strings(labels)
+
+		for _, label := range labels {
+			sb.WriteString(" @" + quoteDSLIdent(label))
+		}
+
+		sb.WriteString("\n")
+	}
+
+	for _, name := range names {
+		printRef(graph.NodeRefs[name])
+	}
+
+	var reuse []string
+	for _, name := range names {
+		ref := graph.NodeRefs[name]
+
+		for _, wrapper := range ref.Wrappers {
+			sb.WriteString(fmt.Sprintf("wrap %s(%s)\n", quoteDSLIdent(wrapper), quoteDSLIdent(name)))
+		}
+
+		if ref.ReUse {
+			reuse = append(reuse, quoteDSLIdent(name))
+		}
+	}
+
+	if len(reuse) > 0 {
+		sb.WriteString("reuse " + strings.Join(reuse, ", ") + "\n")
+	}
+
+	for _, group := range graph.PropsGroups {
+		data, err := json.Marshal(group.Props)
+		if err != nil {
+			return "", err
+		}
+
+		if group.Type != "" {
+			sb.WriteString(fmt.Sprintf("props %s %s\n", quoteDSLIdent(group.Type), data))
+		} else {
+			sb.WriteString(fmt.Sprintf("props @%s %s\n", quoteDSLIdent(group.Label), data))
+		}
+	}
+
+	vertexes := make([]string, 0, len(graph.Vertexes))
+	for name := range graph.Vertexes {
+		vertexes = append(vertexes, name)
+	}
+	sort.Strings(vertexes)
+
+	for _, name := range vertexes {
+		var next []string
+		for _, vertex := range graph.Vertexes[name].Next {
+			next = append(next, vertex.RefRoot.NodeName)
+		}
+		sort.Strings(next)
+
+		for i := range next {
+			next[i] = quoteDSLIdent(next[i])
+		}
+
+		if len(next) > 0 {
+			sb.WriteString(quoteDSLIdent(name) + " -> " + strings.Join(next, ", ") + "\n")
+		} else {
+			sb.WriteString(quoteDSLIdent(name) + "\n")
+		}
+	}
+
+	return sb.String(), nil
+}
@@ -2,6 +2,7 @@ package running
 
 import (
 	"fmt"
+	"sort"
 	"strings"
 	"sync"
 )
@@ -13,9 +14,121 @@ type _DAG struct {
 
 	Warning []string
 
+	// PropsOps changes of props keys caused by renaming, removing or including nodes, applied when plan init
+	PropsOps []_PropsOp
+
+	// PropsGroups props applied to nodes by label or node type, in declaration order
+	PropsGroups []_PropsGroup
+
+	// owner plan initialized with the graph, included plans are recorded to detect cyclic includes
+	owner *Plan
+
+	included map[*Plan]struct{}
+
 	sync.Mutex
 }
 
+// _PropsGroup props of nodes carrying Label or of node type Type
+type _PropsGroup struct {
+	Label, Type string
+
+	Props map[string]interface{}
+}
+
+func (group _PropsGroup) match(ref *_NodeRef) bool {
+	if group.Type != "" {
+		return !ref.Virtual && ref.NodeType == group.Type
+	}
+
+	_, ok := ref.Labels[group.Label]
+	return ok
+}
+
+// withGroupProps return props with values of matched props groups, values set at the node path take precedence,
+// then values of groups, then values inherited from clusters or global props. later declared group takes precedence.
+func (graph *_DAG) withGroupProps(props Props, nodePath string, ref *_NodeRef) Props {
+	return withPropsGroups(graph.PropsGroups, props, nodePath, ref)
+}
+
+// withPropsGroups same as _DAG.withGroupProps, but with given groups, like groups with resolved values
+func withPropsGroups(groups []_PropsGroup, props Props, nodePath string, ref *_NodeRef) Props {
+	var values map[string]interface{}
+
+	for _, group := range groups {
+		if !group.match(ref) {
+			continue
+		}
+
+		if values == nil {
+			values = make(map[string]interface{})
+		}
+
+		for k, v := range group.Props {
+			values[k] = v
+		}
+	}
+
+	if values == nil {
+		return props
+	}
+
+	return _GroupProps{Props: props, NodePath: nodePath, Values: values}
+}
+
+// _GroupProps lookup values of matched props groups before values inherited by the node
+type _GroupProps struct {
+	Props
+
+	NodePath string
+
+	Values map[string]interface{}
+}
+
+func (props _GroupProps) SubGet(sub, key string) (value interface{}, exists bool) {
+	if sub == props.NodePath {
+		if value, exists = props.localGet(sub, key); exists {
+			return
+		}
+	}
+
+	return props.Props.SubGet(sub, key)
+}
+
+func (props _GroupProps) localGet(sub, key string) (value interface{}, exists bool) {
+	if value, exists = localGet(props.Props, sub, key); exists || sub != props.NodePath {
+		return
+	}
+
+	value, exists = props.Values[key]
+	return
+}
+
+func (props _GroupProps) Copy() Props {
+	return _GroupProps{Props: props.Props.Copy(), NodePath: props.NodePath, Values: props.Values}
+}
+
+// _LocalProps props can lookup values set at the node path, without values inherited from clusters or global props
+type _LocalProps interface {
+	localGet(sub, key string) (value interface{}, exists bool)
+}
+
+// localGet lookup value set at the node path, props without inheritance like StandardProps are looked up by SubGet
+func localGet(props Props, sub, key string) (interface{}, bool) {
+	if local, ok := props.(_LocalProps); ok {
+		return local.localGet(sub, key)
+	}
+
+	return props.SubGet(sub, key)
+}
+
+// _PropsOp rename node in props keys from From to To, remove props keys of the node if To is empty.
+// if Props is set, add props which are not set yet instead.
+type _PropsOp struct {
+	From, To string
+
+	Props map[string]interface{}
+}
+
 type _Vertex struct {
 	Prev int
 
@@ -112,6 +225,45 @@ func (graph *_DAG) reset() {
 	}
 }
 
+// WalkNodes visit vertexes and their sub-nodes recursively with node path, example: ClusterA.SubNodeB
+func (graph *_DAG) WalkNodes(visit func(nodePath string, ref *_NodeRef)) {
+	var walk func(path string, ref *_NodeRef)
+	walk = func(path string, ref *_NodeRef) {
+		visit(path, ref)
+
+		for _, sub := range ref.SubRefs {
+			walk(path+"."+sub.NodeName, sub)
+		}
+	}
+
+	names := make([]string, 0, len(graph.Vertexes))
+	for name := range graph.Vertexes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		walk(name, graph.Vertexes[name].RefRoot)
+	}
+}
+
+// UseBuilder check if any node ref of the graph use the builder as node type or wrapper
+func (graph *_DAG) UseBuilder(builder string) bool {
+	for _, ref := range graph.NodeRefs {
+		if ref.NodeType == builder {
+			return true
+		}
+
+		for _, wrapper := range ref.Wrappers {
+			if wrapper == builder {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
 type _NodeRef struct {
 	NodeName string
 
@@ -0,0 +1,267 @@
+package running
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// IncludeOption set how included plan is linked with local nodes
+type IncludeOption func(*_Include)
+
+type _Include struct {
+	After []string
+
+	Before []string
+}
+
+// IncludeAfter link local nodes to entry vertexes of included plan, entry vertexes are vertexes without prev
+var IncludeAfter = func(nodes ...string) IncludeOption {
+	return func(include *_Include) {
+		include.After = append(include.After, nodes...)
+	}
+}
+
+// IncludeBefore link exit vertexes of included plan to local nodes, exit vertexes are vertexes without next
+var IncludeBefore = func(nodes ...string) IncludeOption {
+	return func(include *_Include) {
+		include.Before = append(include.Before, nodes...)
+	}
+}
+
+// IncludePlan copy vertexes, edges, clusters and props of plan register in engine,
+// all included nodes are renamed with prefix, example: prefix "E_" => "A" become "E_A", "A.key" become "E_A.key".
+// props of included plan do not override props already set, global props are copied only if prefix is empty.
+// NestedProps of included plan are copied with inherited values set to each included node, so nodes keep the values.
+// included plan is resolved when plan init, later updates of it take effect after plan init again.
+// a plan can not include itself, directly or through other included plans, it is reported as a warning.
+func (engine *Engine) IncludePlan(planName, prefix string, options ...IncludeOption) Option {
+	include := new(_Include)
+	for _, option := range options {
+		option(include)
+	}
+
+	return func(dag *_DAG) {
+		engine.plansLocker.RLock()
+		plan := engine.plans[planName]
+		engine.plansLocker.RUnlock()
+
+		if plan == nil {
+			dag.Warning = append(dag.Warning, fmt.Sprintf("included plan %s not found", planName))
+			return
+		}
+
+		// plan is locked by Init of itself
+		if dag.owner != nil && plan == dag.owner {
+			dag.Warning = append(dag.Warning, fmt.Sprintf("plan %s can not include itself", planName))
+			return
+		}
+
+		plan.locker.RLock()
+		defer plan.locker.RUnlock()
+
+		source := plan.graph
+		if source == nil {
+			dag.Warning = append(dag.Warning, fmt.Sprintf("included plan %s not initialized", planName))
+			return
+		}
+
+		if _, ok := source.included[dag.owner]; ok && dag.owner != nil {
+			dag.Warning = append(dag.Warning, fmt.Sprintf("include plan %s failed, cyclic include", planName))
+			return
+		}
+
+		for name := range source.NodeRefs {
+			if _, ok := dag.NodeRefs[prefix+name]; ok {
+				dag.Warning = append(dag.Warning,
+					fmt.Sprintf("include plan %s failed, node %s already exists", planName, prefix+name))
+				return
+			}
+		}
+
+		refs := make(map[*_NodeRef]*_NodeRef)
+		var copyRef func(ref *_NodeRef) *_NodeRef
+		copyRef = func(ref *_NodeRef) *_NodeRef {
+			if cp, ok := refs[ref]; ok {
+				return cp
+			}
+
+			cp := &_NodeRef{
+				NodeName: prefix + ref.NodeName,
+				NodeType: ref.NodeType,
+				Wrappers: append([]string(nil), ref.Wrappers...),
+				ReUse:    ref.ReUse,
+				Virtual:  ref.Virtual,
+			}
+			refs[ref] = cp
+
+			if len(ref.Labels) > 0 {
+				cp.Labels = make(map[string]struct{})
+				for label := range ref.Labels {
+					cp.Labels[label] = struct{}{}
+				}
+			}
+
+			for _, sub := range ref.SubRefs {
+				cp.SubRefs = append(cp.SubRefs, copyRef(sub))
+			}
+
+			return cp
+		}
+
+		for _, ref := range source.NodeRefs {
+			cp := copyRef(ref)
+			dag.NodeRefs[cp.NodeName] = cp
+		}
+
+		vertexes := make(map[*_Vertex]*_Vertex)
+		for _, vertex := range source.Vertexes {
+			cp := &_Vertex{RefRoot: refs[vertex.RefRoot]}
+			vertexes[vertex] = cp
+			dag.Vertexes[cp.RefRoot.NodeName] = cp
+		}
+
+		var entries, exits []string
+		for _, vertex := range source.Vertexes {
+			cp := vertexes[vertex]
+			for _, next := range vertex.Next {
+				cp.Next = append(cp.Next, vertexes[next])
+				vertexes[next].Prev++
+			}
+
+			if vertex.Prev == 0 {
+				entries = append(entries, cp.RefRoot.NodeName)
+			}
+
+			if len(vertex.Next) == 0 {
+				exits = append(exits, cp.RefRoot.NodeName)
+			}
+		}
+		sort.Strings(entries)
+		sort.Strings(exits)
+
+		for _, node := range include.After {
+			LinkNodes(append([]string{node}, entries...)...)(dag)
+		}
+
+		for _, node := range include.Before {
+			RLinkNodes(append([]string{node}, exits...)...)(dag)
+		}
+
+		if dag.included == nil {
+			dag.included = make(map[*Plan]struct{})
+		}
+		dag.included[plan] = struct{}{}
+		for p := range source.included {
+			dag.included[p] = struct{}{}
+		}
+
+		var props map[string]interface{}
+		if nested, ok := plan.props.(NestedProps); ok {
+			props = prefixNestedProps(nested, prefix, source)
+		} else if exportable, ok := plan.props.(ExportableProps); ok {
+			props = prefixProps(exportable.Raw(), prefix, source.NodeRefs)
+		} else if plan.props != nil {
+			if _, ok := plan.props.(EmptyProps); !ok {
+				dag.Warning = append(dag.Warning, fmt.Sprintf("props of included plan %s not copied, props not exportable", planName))
+			}
+		}
+
+		if prefix == "" {
+			dag.PropsGroups = append(dag.PropsGroups, source.PropsGroups...)
+		} else if len(source.PropsGroups) > 0 {
+			// props groups would match local nodes, so they are set as props of included nodes
+			if props == nil {
+				props = make(map[string]interface{})
+			}
+
+			var sourceProps Props = EmptyProps{}
+			if plan.props != nil {
+				sourceProps = plan.props
+			}
+
+			// inherited values are copied as props of included nodes, so group values are looked up in the same order as in source plan
+			source.WalkNodes(func(nodePath string, ref *_NodeRef) {
+				groupProps := source.withGroupProps(sourceProps, nodePath, ref)
+				if group, ok := groupProps.(_GroupProps); ok {
+					path := prefixPath(nodePath, prefix, source.NodeRefs)
+					for k := range group.Values {
+						props[path+"."+k], _ = group.SubGet(nodePath, k)
+					}
+				}
+			})
+		}
+
+		if props != nil {
+			dag.PropsOps = append(dag.PropsOps, _PropsOp{Props: props})
+		}
+	}
+}
+
+// prefixProps copy props of nodes, add prefix to node names in node path part of keys,
+// global props are copied only if prefix is empty.
+func prefixProps(raw map[string]interface{}, prefix string, refs map[string]*_NodeRef) map[string]interface{} {
+	props := make(map[string]interface{})
+
+	for key, value := range raw {
+		p := strings.LastIndex(key, ".")
+		if p < 0 || refs[key[:strings.Index(key, ".")]] == nil {
+			if prefix == "" {
+				props[key] = value
+			}
+			continue
+		}
+
+		props[prefixPath(key[:p], prefix, refs)+key[p:]] = value
+	}
+
+	return props
+}
+
+// prefixNestedProps same as prefixProps, but values inherited by each node are set to the node,
+// global values are inherited too if prefix is not empty.
+func prefixNestedProps(nested NestedProps, prefix string, source *_DAG) map[string]interface{} {
+	props := make(map[string]interface{})
+
+	if prefix == "" {
+		for k, v := range nested[""] {
+			props[k] = v
+		}
+	}
+
+	source.WalkNodes(func(nodePath string, ref *_NodeRef) {
+		values := make(map[string]interface{})
+		if prefix != "" {
+			for k, v := range nested[""] {
+				values[k] = v
+			}
+		}
+
+		// the nearest path takes effect
+		segments := strings.Split(nodePath, ".")
+		for i := range segments {
+			for k, v := range nested[strings.Join(segments[:i+1], ".")] {
+				values[k] = v
+			}
+		}
+
+		path := prefixPath(nodePath, prefix, source.NodeRefs)
+		for k, v := range values {
+			props[path+"."+k] = v
+		}
+	})
+
+	return props
+}
+
+// prefixPath add prefix to node names in node path, example: "A.B" => "E_A.E_B"
+func prefixPath(path, prefix string, refs map[string]*_NodeRef) string {
+	segments := strings.Split(path, ".")
+	for i := range segments {
+		if refs[segments[i]] != nil {
+			segments[i] = prefix + segments[i]
+		}
+	}
+
+	return strings.Join(segments, ".")
+}
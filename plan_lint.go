@@ -0,0 +1,191 @@
+package running
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+const (
+	LintRuleWarning         = "warning"
+	LintRuleMissingBuilder  = "missing-builder"
+	LintRuleMissingWrapper  = "missing-wrapper"
+	LintRuleNotWrapper      = "not-wrapper"
+	LintRuleNotCluster      = "not-cluster"
+	LintRuleUnlinkedNode    = "unlinked-node"
+	LintRuleUnusedLabel     = "unused-label"
+	LintRuleIsolatedVirtual = "isolated-virtual"
+	LintRuleBuildFailed     = "build-failed"
+)
+
+// LintIssue a problem of plan found by LintPlan
+type LintIssue struct {
+	Rule string
+
+	// Node name of node ref, empty for plan level issues
+	Node string
+
+	Msg string
+}
+
+func (issue LintIssue) Error() string {
+	if issue.Node == "" {
+		return fmt.Sprintf("[%s] %s", issue.Rule, issue.Msg)
+	}
+
+	return fmt.Sprintf("[%s] %s: %s", issue.Rule, issue.Node, issue.Msg)
+}
+
+// LintIssues all problems of plan found by LintPlan
+type LintIssues []LintIssue
+
+func (issues LintIssues) Error() string {
+	msgs := make([]string, 0, len(issues))
+	for _, issue := range issues {
+		msgs = append(msgs, issue.Error())
+	}
+
+	return fmt.Sprintf("plan lint failed, %d issue(s): %s", len(issues), strings.Join(msgs, "; "))
+}
+
+// LintPlan check plan register in engine against registered builders, return nil if no issue found.
+// report missing builders, wrappers that do not implement Wrapper, non-Cluster nodes given sub-nodes,
+// nodes never linked as vertexes or sub-nodes, labels on non-vertex nodes and virtual nodes with no edges.
+func (engine *Engine) LintPlan(name string) (LintIssues, error) {
+	engine.plansLocker.RLock()
+	plan := engine.plans[name]
+	engine.plansLocker.RUnlock()
+
+	if plan == nil {
+		return nil, fmt.Errorf("%w, plan: %s", ErrPlanNotFound, name)
+	}
+
+	return engine.lintPlan(plan), nil
+}
+
+// lintPlan check initialized plan
+func (engine *Engine) lintPlan(plan *Plan) LintIssues {
+	plan.locker.RLock()
+	defer plan.locker.RUnlock()
+
+	engine.buildersLocker.RLock()
+	defer engine.buildersLocker.RUnlock()
+
+	var issues LintIssues
+	graph := plan.graph
+
+	for _, warning := range graph.Warning {
+		issues = append(issues, LintIssue{Rule: LintRuleWarning, Msg: warning})
+	}
+
+	props := plan.props
+	if props == nil {
+		props = EmptyProps{}
+	}
+
+	// instances built to check interfaces, keyed by builder name
+	instances := make(map[string]Node)
+	instanceOf := func(builderName, nodeName string) (Node, error) {
+		if node, ok := instances[builderName]; ok {
+			return node, nil
+		}
+
+		node, err := buildForLint(engine.builders[builderName], nodeName, props)
+		if err != nil {
+			return nil, err
+		}
+
+		instances[builderName] = node
+		return node, nil
+	}
+
+	isSubNode := make(map[string]bool)
+	for _, ref := range graph.NodeRefs {
+		for _, sub := range ref.SubRefs {
+			isSubNode[sub.NodeName] = true
+		}
+	}
+
+	names := make([]string, 0, len(graph.NodeRefs))
+	for name := range graph.NodeRefs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		ref := graph.NodeRefs[name]
+		vertex := graph.Vertexes[name]
+
+		if vertex == nil && !isSubNode[name] {
+			issues = append(issues, LintIssue{Rule: LintRuleUnlinkedNode, Node: name,
+				Msg: "node is never linked as vertex or merged as sub-node"})
+		}
+
+		if vertex == nil && len(ref.Labels) > 0 {
+			labels := make([]string, 0, len(ref.Labels))
+			for label := range ref.Labels {
+				labels = append(labels, label)
+			}
+			sort.Strings(labels)
+
+			issues = append(issues, LintIssue{Rule: LintRuleUnusedLabel, Node: name,
+				Msg: fmt.Sprintf("labels %v only take effect on vertexes", labels)})
+		}
+
+		if ref.Virtual {
+			if vertex != nil && vertex.Prev == 0 && len(vertex.Next) == 0 {
+				issues = append(issues, LintIssue{Rule: LintRuleIsolatedVirtual, Node: name,
+					Msg: "virtual node has no edges"})
+			}
+		} else if engine.builders[ref.NodeType] == nil {
+			issues = append(issues, LintIssue{Rule: LintRuleMissingBuilder, Node: name,
+				Msg: fmt.Sprintf("no builder found for type %s", ref.NodeType)})
+		} else if len(ref.SubRefs) > 0 {
+			if info, ok := engine.buildersInfo[ref.NodeType]; ok && info.Type != "" {
+				if info.Type != TypeOfCluster {
+					issues = append(issues, LintIssue{Rule: LintRuleNotCluster, Node: name,
+						Msg: fmt.Sprintf("type %s is %s, sub-nodes will be ignored", ref.NodeType, info.Type)})
+				}
+			} else if node, err := instanceOf(ref.NodeType, name); err != nil {
+				issues = append(issues, LintIssue{Rule: LintRuleBuildFailed, Node: name, Msg: err.Error()})
+			} else if _, ok := node.(Cluster); !ok {
+				issues = append(issues, LintIssue{Rule: LintRuleNotCluster, Node: name,
+					Msg: fmt.Sprintf("type %s does not implement Cluster, sub-nodes will be ignored", ref.NodeType)})
+			}
+		}
+
+		for _, wrapper := range ref.Wrappers {
+			if engine.builders[wrapper] == nil {
+				issues = append(issues, LintIssue{Rule: LintRuleMissingWrapper, Node: name,
+					Msg: fmt.Sprintf("no builder found for wrapper %s, it will be skipped", wrapper)})
+			} else if info, ok := engine.buildersInfo[wrapper]; ok && info.Type != "" {
+				if info.Type != TypeOfWrapper {
+					issues = append(issues, LintIssue{Rule: LintRuleNotWrapper, Node: name,
+						Msg: fmt.Sprintf("wrapper %s is %s, it will be skipped", wrapper, info.Type)})
+				}
+			} else if node, err := instanceOf(wrapper, name); err != nil {
+				issues = append(issues, LintIssue{Rule: LintRuleBuildFailed, Node: name, Msg: err.Error()})
+			} else if _, ok := node.(Wrapper); !ok {
+				issues = append(issues, LintIssue{Rule: LintRuleNotWrapper, Node: name,
+					Msg: fmt.Sprintf("wrapper %s does not implement Wrapper, it will be skipped", wrapper)})
+			}
+		}
+	}
+
+	return issues
+}
+
+func buildForLint(builder BuildNodeFunc, name string, props Props) (node Node, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("builder panic when check node, panic info: %v", r)
+		}
+	}()
+
+	node, err = builder(name, props)
+	if err == nil && node == nil {
+		err = fmt.Errorf("builder return nil node")
+	}
+
+	return
+}
@@ -112,6 +112,22 @@ var MarkNodes = func(label string, nodes ...string) Option {
 	}
 }
 
+// PropsForLabel set props of all nodes carrying the label,
+// props of the node set in plan take precedence, then props groups, then defaults of builder.
+var PropsForLabel = func(label string, props map[string]interface{}) Option {
+	return func(dag *_DAG) {
+		dag.PropsGroups = append(dag.PropsGroups, _PropsGroup{Label: label, Props: props})
+	}
+}
+
+// PropsForType set props of all nodes of the node type, example: PropsForType("HttpFetch", {"timeout": 200}).
+// props of the node set in plan take precedence, then props groups, then defaults of builder.
+var PropsForType = func(typ string, props map[string]interface{}) Option {
+	return func(dag *_DAG) {
+		dag.PropsGroups = append(dag.PropsGroups, _PropsGroup{Type: typ, Props: props})
+	}
+}
+
 // LinkNodes link first node with others.
 // example: LinkNodes("A", "B", "C") => A -> B, A -> C.
 var LinkNodes = func(nodes ...string) Option {
@@ -206,3 +222,181 @@ var RLinkNodes = func(nodes ...string) Option {
 		}
 	}
 }
+
+// RemoveNodes remove nodes from graph,
+// edges of the nodes, references as sub-nodes and props keys of the nodes are removed as well.
+var RemoveNodes = func(nodes ...string) Option {
+	return func(dag *_DAG) {
+		for _, node := range nodes {
+			ref, ok := dag.NodeRefs[node]
+			if !ok {
+				dag.Warning = append(dag.Warning, fmt.Sprintf("remove target node %s ref not found", node))
+				continue
+			}
+
+			if vertex := dag.Vertexes[node]; vertex != nil {
+				for _, next := range vertex.Next {
+					next.Prev--
+				}
+
+				for _, other := range dag.Vertexes {
+					other.Next = removeVertex(other.Next, vertex)
+				}
+
+				delete(dag.Vertexes, node)
+			}
+
+			for _, other := range dag.NodeRefs {
+				other.SubRefs = removeRef(other.SubRefs, ref)
+			}
+
+			delete(dag.NodeRefs, node)
+			dag.PropsOps = append(dag.PropsOps, _PropsOp{From: node})
+		}
+	}
+}
+
+// UnlinkNodes remove links from first node to others, opposite of LinkNodes.
+// example: UnlinkNodes("A", "B", "C") => remove A -> B, A -> C.
+var UnlinkNodes = func(nodes ...string) Option {
+	return func(dag *_DAG) {
+		if len(nodes) < 1 {
+			return
+		}
+
+		root := dag.Vertexes[nodes[0]]
+		if root == nil {
+			dag.Warning = append(dag.Warning, fmt.Sprintf("unlink target node %s vertex not found", nodes[0]))
+			return
+		}
+
+		for _, node := range nodes[1:] {
+			next := dag.Vertexes[node]
+			if next == nil {
+				dag.Warning = append(dag.Warning, fmt.Sprintf("unlink target node %s vertex not found", node))
+				continue
+			}
+
+			remain := removeVertex(root.Next, next)
+			if len(remain) == len(root.Next) {
+				dag.Warning = append(dag.Warning, fmt.Sprintf("link %s -> %s not found", nodes[0], node))
+				continue
+			}
+
+			next.Prev -= len(root.Next) - len(remain)
+			root.Next = remain
+		}
+	}
+}
+
+// ReplaceNodeType change type of nodes, other settings of the nodes are kept
+var ReplaceNodeType = func(typ string, nodes ...string) Option {
+	return func(dag *_DAG) {
+		for _, node := range nodes {
+			if ref := dag.NodeRefs[node]; ref == nil {
+				dag.Warning = append(dag.Warning, fmt.Sprintf("replace target node %s ref not found", node))
+			} else if ref.Virtual {
+				dag.Warning = append(dag.Warning, fmt.Sprintf("replace target node %s is virtual", node))
+			} else {
+				ref.NodeType = typ
+			}
+		}
+	}
+}
+
+// UnwrapNodes remove wrapper from nodes, opposite of WrapNodes
+var UnwrapNodes = func(wrapper string, targets ...string) Option {
+	return func(dag *_DAG) {
+		for _, target := range targets {
+			ref := dag.NodeRefs[target]
+			if ref == nil {
+				dag.Warning = append(dag.Warning, fmt.Sprintf("unwrap target node %s ref not found", target))
+				continue
+			}
+
+			var wrappers []string
+			for _, w := range ref.Wrappers {
+				if w != wrapper {
+					wrappers = append(wrappers, w)
+				}
+			}
+
+			if len(wrappers) == len(ref.Wrappers) {
+				dag.Warning = append(dag.Warning, fmt.Sprintf("unwrap target node %s is not wrapped by %s", target, wrapper))
+				continue
+			}
+
+			ref.Wrappers = wrappers
+		}
+	}
+}
+
+// UnmarkNodes remove label of nodes, opposite of MarkNodes
+var UnmarkNodes = func(label string, nodes ...string) Option {
+	return func(dag *_DAG) {
+		for _, node := range nodes {
+			ref := dag.NodeRefs[node]
+			if ref == nil {
+				dag.Warning = append(dag.Warning, fmt.Sprintf("unmark target node %s ref not found", node))
+				continue
+			}
+
+			if _, ok := ref.Labels[label]; !ok {
+				dag.Warning = append(dag.Warning, fmt.Sprintf("unmark target node %s has no label %s", node, label))
+				continue
+			}
+
+			delete(ref.Labels, label)
+		}
+	}
+}
+
+// RenameNode rename node, edges, references as sub-nodes and props keys of the node are kept.
+// example: RenameNode("A", "B") => props key "A.key" and "Cluster.A.key" become "B.key" and "Cluster.B.key".
+var RenameNode = func(from, to string) Option {
+	return func(dag *_DAG) {
+		ref := dag.NodeRefs[from]
+		if ref == nil {
+			dag.Warning = append(dag.Warning, fmt.Sprintf("rename target node %s ref not found", from))
+			return
+		}
+
+		if _, ok := dag.NodeRefs[to]; ok {
+			dag.Warning = append(dag.Warning, fmt.Sprintf("rename node %s failed, node %s already exists", from, to))
+			return
+		}
+
+		ref.NodeName = to
+		dag.NodeRefs[to] = ref
+		delete(dag.NodeRefs, from)
+
+		if vertex := dag.Vertexes[from]; vertex != nil {
+			dag.Vertexes[to] = vertex
+			delete(dag.Vertexes, from)
+		}
+
+		dag.PropsOps = append(dag.PropsOps, _PropsOp{From: from, To: to})
+	}
+}
+
+func removeVertex(vertexes []*_Vertex, target *_Vertex) []*_Vertex {
+	var remain []*_Vertex
+	for _, vertex := range vertexes {
+		if vertex != target {
+			remain = append(remain, vertex)
+		}
+	}
+
+	return remain
+}
+
+func removeRef(refs []*_NodeRef, target *_NodeRef) []*_NodeRef {
+	var remain []*_NodeRef
+	for _, ref := range refs {
+		if ref != target {
+			remain = append(remain, ref)
+		}
+	}
+
+	return remain
+}
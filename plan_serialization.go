@@ -2,13 +2,44 @@ package running
 
 import (
 	"encoding/json"
-	"fmt"
+	"sort"
 )
 
 type JsonPlan struct {
 	Props json.RawMessage
 
 	Graph []GraphNode
+
+	// Base name of plan to inherit, see Plan.Base. nodes of base plan can be linked without type in Graph
+	Base string `json:",omitempty"`
+
+	Overrides *JsonOverrides `json:",omitempty"`
+
+	// NestedProps props grouped by node path, used instead of Props, see NestedProps
+	NestedProps NestedProps `json:",omitempty"`
+
+	// PropsGroups props applied to nodes by label or node type, see PropsForLabel and PropsForType
+	PropsGroups []JsonPropsGroup `json:",omitempty"`
+}
+
+// JsonPropsGroup props applied to all nodes carrying Label or of node type Type, only one of them should be set
+type JsonPropsGroup struct {
+	Label string `json:",omitempty"`
+
+	Type string `json:",omitempty"`
+
+	Props map[string]interface{}
+}
+
+// JsonOverrides changes of nodes inherited from base plan, applied after Graph
+type JsonOverrides struct {
+	// NodeTypes new type of nodes, keyed by node name
+	NodeTypes map[string]string `json:",omitempty"`
+
+	// Wrappers extra wrappers of nodes, keyed by node name
+	Wrappers map[string][]string `json:",omitempty"`
+
+	RemoveNodes []string `json:",omitempty"`
 }
 
 type GraphNode struct {
@@ -42,7 +73,9 @@ func (plan *Plan) MarshalJSON() ([]byte, error) {
 		}
 	}
 
-	if exportable, ok := plan.props.(ExportableProps); ok {
+	if nested, ok := plan.props.(NestedProps); ok {
+		jsonPlan.NestedProps = nested
+	} else if exportable, ok := plan.props.(ExportableProps); ok {
 		propsData, err := json.Marshal(exportable.Raw())
 		if err == nil {
 			jsonPlan.Props = propsData
@@ -51,7 +84,19 @@ func (plan *Plan) MarshalJSON() ([]byte, error) {
 		}
 	}
 
-	for _, vertex := range plan.graph.Vertexes {
+	for _, group := range plan.graph.PropsGroups {
+		jsonPlan.PropsGroups = append(jsonPlan.PropsGroups, JsonPropsGroup{Label: group.Label, Type: group.Type, Props: group.Props})
+	}
+
+	// vertexes are sorted by name, so json patch paths like /Graph/0 are stable
+	names := make([]string, 0, len(plan.graph.Vertexes))
+	for name := range plan.graph.Vertexes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		vertex := plan.graph.Vertexes[name]
 		node := newJsonNode(vertex.RefRoot)
 
 		next := make([]string, 0)
@@ -82,6 +127,7 @@ func newJsonNode(ref *_NodeRef) *JsonNode {
 		for label := range ref.Labels {
 			node.Labels = append(node.Labels, label)
 		}
+		sort.Strings(node.Labels)
 	}
 
 	for _, subRef := range ref.SubRefs {
@@ -92,6 +138,16 @@ func newJsonNode(ref *_NodeRef) *JsonNode {
 }
 
 func (plan *Plan) UnmarshalJSON(bytes []byte) error {
+	return plan.unmarshalJSON(bytes, nil)
+}
+
+// unmarshalJSON decode json form of plan, props values masked by SecretMask are restored from secrets
+// at the same props path of old plan, so a plan exported and decoded again keeps its secrets.
+func (plan *Plan) unmarshalJSON(bytes []byte, old *Plan) error {
+	if err := ValidateJsonPlan(bytes); err != nil {
+		return err
+	}
+
 	jsonPlan := new(JsonPlan)
 
 	err := json.Unmarshal(bytes, jsonPlan)
@@ -99,75 +155,156 @@ func (plan *Plan) UnmarshalJSON(bytes []byte) error {
 		return err
 	}
 
-	graph := newDAG()
-	for _, part := range jsonPlan.Graph {
-		parseRefFromJsonNode(part.Node, graph)
+	propsMap := make(map[string]interface{})
+	if len(jsonPlan.Props) > 0 {
+		if err = json.Unmarshal(jsonPlan.Props, &propsMap); err != nil {
+			return err
+		}
+	}
+
+	if len(jsonPlan.NestedProps) > 0 {
+		plan.Props = jsonPlan.NestedProps
+	} else {
+		plan.Props = StandardProps(propsMap)
+	}
+	if old != nil {
+		restoreSecrets(old, plan.Props, jsonPlan.PropsGroups)
+	}
+
+	plan.Options = jsonPlan.Options()
+	plan.Base = jsonPlan.Base
 
-		graph.Vertexes[part.Node.Name] = &_Vertex{
-			RefRoot: graph.NodeRefs[part.Node.Name],
+	return plan.Init()
+}
+
+// restoreSecrets set secrets of old plan back to masked values of props and props groups
+func restoreSecrets(old *Plan, props Props, groups []JsonPropsGroup) {
+	if old.graph == nil {
+		return
+	}
+
+	restore := func(raw, oldRaw map[string]interface{}) {
+		for key, value := range raw {
+			if secret, ok := oldRaw[key].(Secret); ok && value == SecretMask {
+				raw[key] = secret
+			}
 		}
 	}
 
-	for _, part := range jsonPlan.Graph {
-		if graph.Vertexes[part.Node.Name] == nil {
-			continue
+	if nested, ok := props.(NestedProps); ok {
+		if oldNested, ok := old.props.(NestedProps); ok {
+			for path, values := range nested {
+				restore(values, oldNested[path])
+			}
+		}
+	} else if exportable, ok := props.(ExportableProps); ok {
+		if oldExportable, ok := old.props.(ExportableProps); ok {
+			restore(exportable.Raw(), oldExportable.Raw())
 		}
+	}
 
-		for _, node := range part.NextNodes {
-			if graph.Vertexes[node] == nil {
-				continue
+	for _, group := range groups {
+		for _, oldGroup := range old.graph.PropsGroups {
+			if oldGroup.Label == group.Label && oldGroup.Type == group.Type {
+				restore(group.Props, oldGroup.Props)
 			}
+		}
+	}
+}
 
-			graph.Vertexes[part.Node.Name].Next = append(graph.Vertexes[part.Node.Name].Next,
-				graph.Vertexes[node])
+// Options convert json plan to equivalent options, so the plan can be patched by UpdatePlan like plans built in code.
+// nodes are declared first, sub-nodes before clusters, then vertexes are linked.
+func (jsonPlan *JsonPlan) Options() []Option {
+	var options []Option
 
-			graph.Vertexes[node].Prev++
+	declared := make(map[string]bool)
+	for _, part := range jsonPlan.Graph {
+		if part.Node != nil {
+			options = appendNodeOptions(options, part.Node, declared)
 		}
 	}
 
-	if err = graph.Verify(); err != nil {
-		return fmt.Errorf("invalid plan, %w", err)
+	for _, part := range jsonPlan.Graph {
+		if part.Node == nil {
+			continue
+		}
+
+		options = append(options, LinkNodes(append([]string{part.Node.Name}, part.NextNodes...)...))
 	}
 
-	plan.graph = graph
+	if overrides := jsonPlan.Overrides; overrides != nil {
+		if len(overrides.RemoveNodes) > 0 {
+			options = append(options, RemoveNodes(overrides.RemoveNodes...))
+		}
 
-	propsMap := make(map[string]interface{})
-	err = json.Unmarshal(jsonPlan.Props, &propsMap)
-	if err != nil {
-		return err
-	} else {
-		plan.props = StandardProps(propsMap)
-		plan.Props = plan.props.Copy()
+		names := make([]string, 0, len(overrides.NodeTypes))
+		for name := range overrides.NodeTypes {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			options = append(options, ReplaceNodeType(overrides.NodeTypes[name], name))
+		}
+
+		names = make([]string, 0, len(overrides.Wrappers))
+		for name := range overrides.Wrappers {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			for _, wrapper := range overrides.Wrappers[name] {
+				options = append(options, WrapNodes(wrapper, name))
+			}
+		}
+	}
+
+	for _, group := range jsonPlan.PropsGroups {
+		if group.Type != "" {
+			options = append(options, PropsForType(group.Type, group.Props))
+		} else {
+			options = append(options, PropsForLabel(group.Label, group.Props))
+		}
 	}
 
-	return nil
+	return options
 }
 
-func parseRefFromJsonNode(node *JsonNode, graph *_DAG) *_NodeRef {
-	if graph.NodeRefs[node.Name] != nil {
-		return graph.NodeRefs[node.Name]
+// appendNodeOptions append options to declare node, only the first definition of a node takes effect
+func appendNodeOptions(options []Option, node *JsonNode, declared map[string]bool) []Option {
+	if declared[node.Name] {
+		return options
 	}
+	declared[node.Name] = true
 
-	ref := &_NodeRef{
-		NodeName: node.Name,
-		NodeType: node.Type,
-		Wrappers: node.Wrappers,
-		ReUse:    node.ReUse,
-		Virtual:  node.Virtual,
+	var subNodes []string
+	for _, subNode := range node.SubNodes {
+		options = appendNodeOptions(options, subNode, declared)
+		subNodes = append(subNodes, subNode.Name)
 	}
 
-	if len(node.Labels) > 0 {
-		ref.Labels = make(map[string]struct{}, len(node.Labels))
+	if node.Virtual {
+		options = append(options, AddVirtualNodes(node.Name))
+	} else {
+		options = append(options, AddNodes(node.Type, node.Name))
+	}
 
-		for _, label := range node.Labels {
-			ref.Labels[label] = struct{}{}
-		}
+	if len(subNodes) > 0 {
+		options = append(options, MergeNodes(node.Name, subNodes...))
 	}
 
-	for _, subNode := range node.SubNodes {
-		ref.SubRefs = append(ref.SubRefs, parseRefFromJsonNode(subNode, graph))
+	for _, wrapper := range node.Wrappers {
+		options = append(options, WrapNodes(wrapper, node.Name))
+	}
+
+	if node.ReUse {
+		options = append(options, ReUseNodes(node.Name))
+	}
+
+	for _, label := range node.Labels {
+		options = append(options, MarkNodes(label, node.Name))
 	}
 
-	graph.NodeRefs[node.Name] = ref
-	return ref
+	return options
 }
@@ -0,0 +1,277 @@
+package running
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// PlanStore persist plans in json format, engine can load and watch plans from it
+type PlanStore interface {
+	// List return names of all plans in store
+	List() ([]string, error)
+
+	// Get return json data of the plan
+	Get(name string) ([]byte, error)
+
+	// Put save json data of the plan
+	Put(name string, data []byte) error
+
+	// Watch notify changes of plans until ctx done
+	Watch(ctx context.Context) (<-chan PlanEvent, error)
+}
+
+// PlanEvent describe a change of plan in store
+type PlanEvent struct {
+	Name string
+
+	// Removed plan had been removed from store
+	Removed bool
+}
+
+// PlanLoadError record the latest failure of loading plan from store
+type PlanLoadError struct {
+	Time time.Time
+
+	Err error
+}
+
+const planFileExt = ".json"
+
+// FilePlanStore store one plan json per file in a directory, file name is plan name with .json extension
+type FilePlanStore struct {
+	Dir string
+
+	// Interval polling interval of Watch
+	Interval time.Duration
+}
+
+// NewFilePlanStore new a file plan store.
+// dir: directory of plan files.
+// interval: polling interval of Watch, default to 1 second if not positive.
+func NewFilePlanStore(dir string, interval time.Duration) *FilePlanStore {
+	if interval <= 0 {
+		interval = time.Second
+	}
+
+	return &FilePlanStore{Dir: dir, Interval: interval}
+}
+
+func (store *FilePlanStore) List() ([]string, error) {
+	entries, err := os.ReadDir(store.Dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.HasSuffix(entry.Name(), planFileExt) {
+			names = append(names, strings.TrimSuffix(entry.Name(), planFileExt))
+		}
+	}
+
+	sort.Strings(names)
+	return names, nil
+}
+
+func (store *FilePlanStore) Get(name string) ([]byte, error) {
+	path, err := store.path(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return os.ReadFile(path)
+}
+
+// Put write plan to a temp file then rename it, so Watch never see a partial file
+func (store *FilePlanStore) Put(name string, data []byte) error {
+	path, err := store.path(name)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(store.Dir, "."+name+".*.tmp")
+	if err != nil {
+		return err
+	}
+
+	if _, err = tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	if err = tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
+
+func (store *FilePlanStore) Watch(ctx context.Context) (<-chan PlanEvent, error) {
+	stamps, err := store.stamps()
+	if err != nil {
+		return nil, err
+	}
+
+	events := make(chan PlanEvent)
+
+	go func() {
+		defer close(events)
+
+		ticker := time.NewTicker(store.Interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			latest, err := store.stamps()
+			if err != nil {
+				continue
+			}
+
+			var changes []PlanEvent
+			for name, stamp := range latest {
+				if old, ok := stamps[name]; !ok || old != stamp {
+					changes = append(changes, PlanEvent{Name: name})
+				}
+			}
+
+			for name := range stamps {
+				if _, ok := latest[name]; !ok {
+					changes = append(changes, PlanEvent{Name: name, Removed: true})
+				}
+			}
+
+			stamps = latest
+
+			for _, event := range changes {
+				select {
+				case events <- event:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+
+	return events, nil
+}
+
+type _FileStamp struct {
+	ModTime time.Time
+
+	Size int64
+}
+
+func (store *FilePlanStore) stamps() (map[string]_FileStamp, error) {
+	names, err := store.List()
+	if err != nil {
+		return nil, err
+	}
+
+	stamps := make(map[string]_FileStamp, len(names))
+	for _, name := range names {
+		path, err := store.path(name)
+		if err != nil {
+			continue
+		}
+
+		if info, err := os.Stat(path); err == nil {
+			stamps[name] = _FileStamp{ModTime: info.ModTime(), Size: info.Size()}
+		}
+	}
+
+	return stamps, nil
+}
+
+// path return file path of the plan, names which are not a single file name are rejected, so files outside Dir are not accessed
+func (store *FilePlanStore) path(name string) (string, error) {
+	if name == "" || name == "." || name == ".." || filepath.Base(name) != name || strings.ContainsAny(name, `/\`) {
+		return "", fmt.Errorf("invalid plan name %q for file plan store", name)
+	}
+
+	return filepath.Join(store.Dir, name+planFileExt), nil
+}
+
+// LoadPlansFromStore load all plans from store.
+// plans failed to load are skipped and reported through Inspector, return the first error.
+func (engine *Engine) LoadPlansFromStore(store PlanStore) error {
+	names, err := store.List()
+	if err != nil {
+		return err
+	}
+
+	var firstErr error
+	for _, name := range names {
+		if err = engine.loadPlanFromStore(store, name); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	return firstErr
+}
+
+// WatchPlanStore load all plans from store, then apply changes of store until ctx done.
+// if a plan failed to load, the previous version keeps running and the error is reported through Inspector.
+func (engine *Engine) WatchPlanStore(ctx context.Context, store PlanStore) error {
+	events, err := store.Watch(ctx)
+	if err != nil {
+		return err
+	}
+
+	_ = engine.LoadPlansFromStore(store)
+
+	go func() {
+		for event := range events {
+			if event.Removed {
+				_ = engine.RemovePlan(event.Name)
+				engine.setPlanLoadError(event.Name, nil)
+			} else {
+				_ = engine.loadPlanFromStore(store, event.Name)
+			}
+		}
+	}()
+
+	return nil
+}
+
+func (engine *Engine) loadPlanFromStore(store PlanStore, name string) error {
+	data, err := store.Get(name)
+	if err == nil {
+		err = engine.LoadPlanFromJson(name, data, nil)
+	}
+
+	if err != nil {
+		err = fmt.Errorf("failed to load plan %s from store, %w", name, err)
+		engine.setPlanLoadError(name, err)
+		return err
+	}
+
+	engine.setPlanLoadError(name, nil)
+	return nil
+}
+
+func (engine *Engine) setPlanLoadError(name string, err error) {
+	engine.loadErrorsLocker.Lock()
+	defer engine.loadErrorsLocker.Unlock()
+
+	if err == nil {
+		delete(engine.loadErrors, name)
+		return
+	}
+
+	if engine.loadErrors == nil {
+		engine.loadErrors = make(map[string]PlanLoadError)
+	}
+	engine.loadErrors[name] = PlanLoadError{Time: time.Now(), Err: err}
+}
@@ -0,0 +1,236 @@
+package running
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// PlanTemplate json plan with parameters, parameters are referenced as ${name} in node names, types, props keys and values.
+// a json string which is exactly a reference is replaced by the typed value, example: "${shard_count}" => 3,
+// otherwise the value is formatted into the string, example: "Shard${shard_index}" => "Shard1".
+// parameter names are identifiers, other references like ${env:KEY} are kept as is.
+type PlanTemplate struct {
+	// Params declare parameters, Type and Required are checked and Default is used when not set
+	Params []PropSchema
+
+	Plan json.RawMessage
+}
+
+type _PlanTemplate struct {
+	Template PlanTemplate
+
+	// Instances params of plans instantiated from the template, keyed by plan name
+	Instances map[string]map[string]interface{}
+}
+
+var templateParamRegexp = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// RegisterPlanTemplate register plan template to engine, every parameter referenced by the plan must be declared.
+// register again with the same name replaces the template, plans instantiated before are not changed.
+func (engine *Engine) RegisterPlanTemplate(name string, template PlanTemplate) error {
+	var doc interface{}
+	if err := json.Unmarshal(template.Plan, &doc); err != nil {
+		return fmt.Errorf("invalid plan template %s, %w", name, err)
+	}
+
+	declared := make(map[string]bool)
+	var errs []string
+
+	for _, param := range template.Params {
+		if !templateParamRegexp.MatchString("${" + param.Name + "}") {
+			errs = append(errs, fmt.Sprintf("invalid param name %q", param.Name))
+		} else if declared[param.Name] {
+			errs = append(errs, fmt.Sprintf("param %s declared twice", param.Name))
+		} else if param.Default != nil && !CheckPropValue(param.Type, param.Default) {
+			errs = append(errs, fmt.Sprintf("default of param %s expect %s, got %T", param.Name, param.Type, param.Default))
+		}
+
+		declared[param.Name] = true
+	}
+
+	var undeclared []string
+	walkTemplateStrings(doc, func(s string) {
+		for _, match := range templateParamRegexp.FindAllStringSubmatch(s, -1) {
+			if !declared[match[1]] {
+				declared[match[1]] = true
+				undeclared = append(undeclared, match[1])
+			}
+		}
+	})
+	sort.Strings(undeclared)
+
+	for _, param := range undeclared {
+		errs = append(errs, fmt.Sprintf("param %s not declared", param))
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("invalid plan template %s, %s", name, strings.Join(errs, "; "))
+	}
+
+	engine.templatesLocker.Lock()
+	defer engine.templatesLocker.Unlock()
+
+	if engine.templates == nil {
+		engine.templates = make(map[string]*_PlanTemplate)
+	}
+
+	if t := engine.templates[name]; t != nil {
+		t.Template = template
+	} else {
+		engine.templates[name] = &_PlanTemplate{Template: template, Instances: make(map[string]map[string]interface{})}
+	}
+
+	return nil
+}
+
+// InstantiatePlan render plan template with params, then load it as plan into engine like LoadPlanFromJson,
+// plan of the same name is replaced and its worker pool is cleared
+func (engine *Engine) InstantiatePlan(template, name string, params map[string]interface{}) error {
+	engine.templatesLocker.Lock()
+	t := engine.templates[template]
+	var planTemplate PlanTemplate
+	if t != nil {
+		planTemplate = t.Template
+	}
+	engine.templatesLocker.Unlock()
+
+	if t == nil {
+		return fmt.Errorf("%w, template: %s", ErrPlanTemplateNotFound, template)
+	}
+
+	values, err := resolveTemplateParams(planTemplate.Params, params)
+	if err != nil {
+		return fmt.Errorf("failed to instantiate plan %s from template %s, %w", name, template, err)
+	}
+
+	var doc interface{}
+	if err = json.Unmarshal(planTemplate.Plan, &doc); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(renderTemplateValue(doc, values))
+	if err != nil {
+		return err
+	}
+
+	if err = engine.LoadPlanFromJson(name, data, nil); err != nil {
+		return fmt.Errorf("failed to instantiate plan %s from template %s, %w", name, template, err)
+	}
+
+	engine.templatesLocker.Lock()
+	for _, other := range engine.templates {
+		delete(other.Instances, name)
+	}
+	t.Instances[name] = values
+	engine.templatesLocker.Unlock()
+
+	return nil
+}
+
+// resolveTemplateParams check params against declaration and fill defaults
+func resolveTemplateParams(schemas []PropSchema, params map[string]interface{}) (map[string]interface{}, error) {
+	values := make(map[string]interface{})
+	declared := make(map[string]bool)
+	var errs []string
+
+	for _, schema := range schemas {
+		declared[schema.Name] = true
+
+		value, ok := params[schema.Name]
+		if !ok {
+			if schema.Default != nil {
+				values[schema.Name] = schema.Default
+			} else if schema.Required {
+				errs = append(errs, fmt.Sprintf("param %s is required", schema.Name))
+			}
+			continue
+		}
+
+		if !CheckPropValue(schema.Type, value) {
+			errs = append(errs, fmt.Sprintf("param %s expect %s, got %T", schema.Name, schema.Type, value))
+			continue
+		}
+
+		values[schema.Name] = value
+	}
+
+	var unknown []string
+	for name := range params {
+		if !declared[name] {
+			unknown = append(unknown, name)
+		}
+	}
+	sort.Strings(unknown)
+
+	for _, name := range unknown {
+		errs = append(errs, fmt.Sprintf("param %s not declared", name))
+	}
+
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("invalid params, %s", strings.Join(errs, "; "))
+	}
+
+	return values, nil
+}
+
+// renderTemplateValue replace parameter references in strings and object keys of json value,
+// params not set are rendered as null or empty string
+func renderTemplateValue(raw interface{}, values map[string]interface{}) interface{} {
+	switch v := raw.(type) {
+	case string:
+		if match := templateParamRegexp.FindStringSubmatch(v); match != nil && match[0] == v {
+			return values[match[1]]
+		}
+
+		return renderTemplateText(v, values)
+	case []interface{}:
+		rendered := make([]interface{}, len(v))
+		for i, item := range v {
+			rendered[i] = renderTemplateValue(item, values)
+		}
+		return rendered
+	case map[string]interface{}:
+		rendered := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			rendered[renderTemplateText(key, values)] = renderTemplateValue(item, values)
+		}
+		return rendered
+	default:
+		return raw
+	}
+}
+
+func renderTemplateText(s string, values map[string]interface{}) string {
+	return templateParamRegexp.ReplaceAllStringFunc(s, func(ref string) string {
+		switch value := values[ref[2:len(ref)-1]].(type) {
+		case nil:
+			return ""
+		case float64:
+			return strconv.FormatFloat(value, 'f', -1, 64)
+		case float32:
+			return strconv.FormatFloat(float64(value), 'f', -1, 32)
+		default:
+			return fmt.Sprint(value)
+		}
+	})
+}
+
+func walkTemplateStrings(raw interface{}, visit func(s string)) {
+	switch v := raw.(type) {
+	case string:
+		visit(v)
+	case []interface{}:
+		for _, item := range v {
+			walkTemplateStrings(item, visit)
+		}
+	case map[string]interface{}:
+		for key, item := range v {
+			visit(key)
+			walkTemplateStrings(item, visit)
+		}
+	}
+}
@@ -0,0 +1,507 @@
+package running
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// JsonPlanSchema JSON Schema of JsonPlan
+//
+//go:embed plan.schema.json
+var JsonPlanSchema string
+
+// PlanValidationError a problem found in json plan, Path is JSON path of the problem, example: $.Graph[0].NextNodes[1]
+type PlanValidationError struct {
+	Path string
+
+	Msg string
+}
+
+func (err PlanValidationError) Error() string {
+	return err.Path + ": " + err.Msg
+}
+
+// PlanValidationErrors all problems found in json plan
+type PlanValidationErrors []PlanValidationError
+
+func (errs PlanValidationErrors) Error() string {
+	msgs := make([]string, 0, len(errs))
+	for _, err := range errs {
+		msgs = append(msgs, err.Error())
+	}
+
+	return fmt.Sprintf("invalid plan, %d problem(s): %s", len(errs), strings.Join(msgs, "; "))
+}
+
+// ValidateJsonPlan validate json plan against JsonPlanSchema and graph rules,
+// return PlanValidationErrors with every problem found, or nil if the plan is valid.
+func ValidateJsonPlan(data []byte) error {
+	var raw interface{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return PlanValidationErrors{{Path: "$", Msg: err.Error()}}
+	}
+
+	validator := &_PlanValidator{defs: make(map[string]_NodeDef)}
+	validator.validatePlan(raw)
+
+	if len(validator.errs) > 0 {
+		return validator.errs
+	}
+
+	return nil
+}
+
+type _PlanValidator struct {
+	errs PlanValidationErrors
+
+	// defs first definition of each node name
+	defs map[string]_NodeDef
+
+	// subs sub-node names of each node, used to detect cycle between clusters
+	subs map[string][]string
+
+	// inherit plan has base plan, nodes may be defined in base plan
+	inherit bool
+}
+
+type _NodeDef struct {
+	Path string
+
+	Type string
+
+	Virtual bool
+}
+
+func (validator *_PlanValidator) addError(path string, format string, args ...interface{}) {
+	validator.errs = append(validator.errs, PlanValidationError{Path: path, Msg: fmt.Sprintf(format, args...)})
+}
+
+func (validator *_PlanValidator) validatePlan(raw interface{}) {
+	plan, ok := raw.(map[string]interface{})
+	if !ok {
+		validator.addError("$", "expect object, got %s", jsonTypeName(raw))
+		return
+	}
+
+	if props, ok := jsonField(plan, "Props"); ok && props != nil {
+		if _, ok = props.(map[string]interface{}); !ok {
+			validator.addError("$.Props", "expect object, got %s", jsonTypeName(props))
+		}
+	}
+
+	if nestedRaw, ok := jsonField(plan, "NestedProps"); ok && nestedRaw != nil {
+		if nested, ok := nestedRaw.(map[string]interface{}); !ok {
+			validator.addError("$.NestedProps", "expect object, got %s", jsonTypeName(nestedRaw))
+		} else {
+			for path, values := range nested {
+				if _, ok = values.(map[string]interface{}); !ok && values != nil {
+					validator.addError(fmt.Sprintf("$.NestedProps[%q]", path), "expect object, got %s", jsonTypeName(values))
+				}
+			}
+
+			if props, _ := jsonField(plan, "Props"); len(nested) > 0 && props != nil {
+				if flat, ok := props.(map[string]interface{}); ok && len(flat) > 0 {
+					validator.addError("$.NestedProps", "can not be used with Props")
+				}
+			}
+		}
+	}
+
+	if groups, ok := jsonField(plan, "PropsGroups"); ok && groups != nil {
+		validator.validatePropsGroups(groups)
+	}
+
+	if base, ok := jsonField(plan, "Base"); ok && base != nil {
+		name, ok := base.(string)
+		if !ok {
+			validator.addError("$.Base", "expect string, got %s", jsonTypeName(base))
+		}
+		validator.inherit = name != ""
+	}
+
+	if overrides, ok := jsonField(plan, "Overrides"); ok && overrides != nil {
+		validator.validateOverrides(overrides)
+	}
+
+	graph, _ := jsonField(plan, "Graph")
+	if graph == nil {
+		return
+	}
+
+	parts, ok := graph.([]interface{})
+	if !ok {
+		validator.addError("$.Graph", "expect array, got %s", jsonTypeName(graph))
+		return
+	}
+
+	vertexes := make(map[string]int) // vertex name => index of graph node
+	nextNodes := make(map[string][]string)
+	validator.subs = make(map[string][]string)
+
+	for i, part := range parts {
+		path := fmt.Sprintf("$.Graph[%d]", i)
+
+		partObj, ok := part.(map[string]interface{})
+		if !ok {
+			validator.addError(path, "expect object, got %s", jsonTypeName(part))
+			continue
+		}
+
+		node, ok := jsonField(partObj, "Node")
+		if !ok || node == nil {
+			validator.addError(path+".Node", "node is required")
+			continue
+		}
+
+		name := validator.validateNode(node, path+".Node")
+		if name == "" {
+			continue
+		}
+
+		if j, ok := vertexes[name]; ok {
+			validator.addError(path+".Node.Name", "duplicate vertex name %s, first defined at $.Graph[%d]", name, j)
+			continue
+		}
+		vertexes[name] = i
+	}
+
+	for i, part := range parts {
+		path := fmt.Sprintf("$.Graph[%d]", i)
+
+		partObj, _ := part.(map[string]interface{})
+		next, _ := jsonField(partObj, "NextNodes")
+		if next == nil {
+			continue
+		}
+
+		nextList, ok := next.([]interface{})
+		if !ok {
+			validator.addError(path+".NextNodes", "expect array, got %s", jsonTypeName(next))
+			continue
+		}
+
+		node, _ := jsonField(partObj, "Node")
+		nodeObj, _ := node.(map[string]interface{})
+		nameRaw, _ := jsonField(nodeObj, "Name")
+		name, _ := nameRaw.(string)
+
+		for k, nextRaw := range nextList {
+			nextPath := fmt.Sprintf("%s.NextNodes[%d]", path, k)
+
+			nextName, ok := nextRaw.(string)
+			if !ok {
+				validator.addError(nextPath, "expect string, got %s", jsonTypeName(nextRaw))
+				continue
+			}
+
+			if _, ok = vertexes[nextName]; !ok && !validator.inherit {
+				validator.addError(nextPath, "unknown vertex %s", nextName)
+				continue
+			}
+
+			if j, ok := vertexes[name]; ok && j == i {
+				nextNodes[name] = append(nextNodes[name], nextName)
+			}
+		}
+	}
+
+	for _, cycle := range findCycles(nextNodes) {
+		first := len(parts)
+		for _, name := range cycle {
+			if vertexes[name] < first {
+				first = vertexes[name]
+			}
+		}
+
+		validator.addError(fmt.Sprintf("$.Graph[%d]", first), "found cycle between nodes: %v", cycle)
+	}
+
+	for _, cycle := range findCycles(validator.subs) {
+		validator.addError(validator.defs[cycle[0]].Path, "found cycle between clusters: %v", cycle)
+	}
+}
+
+// validateNode validate json node and its sub-nodes, return name of the node
+func (validator *_PlanValidator) validateNode(raw interface{}, path string) string {
+	node, ok := raw.(map[string]interface{})
+	if !ok {
+		validator.addError(path, "expect object, got %s", jsonTypeName(raw))
+		return ""
+	}
+
+	var def _NodeDef
+	def.Path = path
+
+	nameRaw, _ := jsonField(node, "Name")
+	name, ok := nameRaw.(string)
+	if !ok {
+		validator.addError(path+".Name", "expect string, got %s", jsonTypeName(nameRaw))
+	} else if name == "" {
+		validator.addError(path+".Name", "name is required")
+	}
+
+	if typeRaw, ok := jsonField(node, "Type"); ok && typeRaw != nil {
+		if def.Type, ok = typeRaw.(string); !ok {
+			validator.addError(path+".Type", "expect string, got %s", jsonTypeName(typeRaw))
+		}
+	}
+
+	for _, key := range []string{"ReUse", "Virtual"} {
+		if flag, ok := jsonField(node, key); ok && flag != nil {
+			if b, ok := flag.(bool); !ok {
+				validator.addError(path+"."+key, "expect boolean, got %s", jsonTypeName(flag))
+			} else if key == "Virtual" {
+				def.Virtual = b
+			}
+		}
+	}
+
+	for _, key := range []string{"Wrappers", "Labels"} {
+		validator.validateStrings(node, key, path)
+	}
+
+	if def.Type == "" && !def.Virtual && name != "" && !validator.inherit {
+		validator.addError(path+".Type", "type of node %s is required", name)
+	}
+
+	if name != "" {
+		if first, ok := validator.defs[name]; !ok {
+			validator.defs[name] = def
+		} else if first.Type != def.Type || first.Virtual != def.Virtual {
+			validator.addError(path, "node %s defined twice with different types, %s at %s and %s here",
+				name, describeNodeDef(first), first.Path, describeNodeDef(def))
+		}
+	}
+
+	if subNodes, ok := jsonField(node, "SubNodes"); ok && subNodes != nil {
+		subList, ok := subNodes.([]interface{})
+		if !ok {
+			validator.addError(path+".SubNodes", "expect array, got %s", jsonTypeName(subNodes))
+		} else {
+			for i, sub := range subList {
+				subName := validator.validateNode(sub, fmt.Sprintf("%s.SubNodes[%d]", path, i))
+				if name != "" && subName != "" {
+					validator.subs[name] = append(validator.subs[name], subName)
+				}
+			}
+		}
+	}
+
+	return name
+}
+
+func (validator *_PlanValidator) validatePropsGroups(raw interface{}) {
+	groups, ok := raw.([]interface{})
+	if !ok {
+		validator.addError("$.PropsGroups", "expect array, got %s", jsonTypeName(raw))
+		return
+	}
+
+	for i, groupRaw := range groups {
+		path := fmt.Sprintf("$.PropsGroups[%d]", i)
+
+		group, ok := groupRaw.(map[string]interface{})
+		if !ok {
+			validator.addError(path, "expect object, got %s", jsonTypeName(groupRaw))
+			continue
+		}
+
+		var selectors []string
+		for _, key := range []string{"Label", "Type"} {
+			value, _ := jsonField(group, key)
+			if value == nil {
+				continue
+			}
+
+			if s, ok := value.(string); !ok {
+				validator.addError(path+"."+key, "expect string, got %s", jsonTypeName(value))
+			} else if s != "" {
+				selectors = append(selectors, key)
+			}
+		}
+
+		if len(selectors) != 1 {
+			validator.addError(path, "expect one of Label and Type, got %d", len(selectors))
+		}
+
+		if props, _ := jsonField(group, "Props"); props != nil {
+			if _, ok = props.(map[string]interface{}); !ok {
+				validator.addError(path+".Props", "expect object, got %s", jsonTypeName(props))
+			}
+		}
+	}
+}
+
+func (validator *_PlanValidator) validateOverrides(raw interface{}) {
+	overrides, ok := raw.(map[string]interface{})
+	if !ok {
+		validator.addError("$.Overrides", "expect object, got %s", jsonTypeName(raw))
+		return
+	}
+
+	if !validator.inherit {
+		validator.addError("$.Overrides", "overrides require base plan")
+	}
+
+	if nodeTypes, ok := jsonField(overrides, "NodeTypes"); ok && nodeTypes != nil {
+		if types, ok := nodeTypes.(map[string]interface{}); !ok {
+			validator.addError("$.Overrides.NodeTypes", "expect object, got %s", jsonTypeName(nodeTypes))
+		} else {
+			for name, typ := range types {
+				if _, ok = typ.(string); !ok {
+					validator.addError("$.Overrides.NodeTypes."+name, "expect string, got %s", jsonTypeName(typ))
+				}
+			}
+		}
+	}
+
+	if wrappersRaw, ok := jsonField(overrides, "Wrappers"); ok && wrappersRaw != nil {
+		if wrappers, ok := wrappersRaw.(map[string]interface{}); !ok {
+			validator.addError("$.Overrides.Wrappers", "expect object, got %s", jsonTypeName(wrappersRaw))
+		} else {
+			for name := range wrappers {
+				validator.validateStrings(wrappers, name, "$.Overrides.Wrappers")
+			}
+		}
+	}
+
+	validator.validateStrings(overrides, "RemoveNodes", "$.Overrides")
+}
+
+func (validator *_PlanValidator) validateStrings(node map[string]interface{}, key string, path string) {
+	raw, ok := jsonField(node, key)
+	if !ok || raw == nil {
+		return
+	}
+
+	list, ok := raw.([]interface{})
+	if !ok {
+		validator.addError(path+"."+key, "expect array, got %s", jsonTypeName(raw))
+		return
+	}
+
+	for i, item := range list {
+		if _, ok = item.(string); !ok {
+			validator.addError(fmt.Sprintf("%s.%s[%d]", path, key, i), "expect string, got %s", jsonTypeName(item))
+		}
+	}
+}
+
+func describeNodeDef(def _NodeDef) string {
+	if def.Virtual {
+		return "virtual"
+	}
+
+	return fmt.Sprintf("type %q", def.Type)
+}
+
+// jsonField lookup field of json object, key is matched case-insensitively as encoding/json does
+func jsonField(obj map[string]interface{}, key string) (interface{}, bool) {
+	if value, ok := obj[key]; ok {
+		return value, true
+	}
+
+	for k, value := range obj {
+		if strings.EqualFold(k, key) {
+			return value, true
+		}
+	}
+
+	return nil, false
+}
+
+func jsonTypeName(raw interface{}) string {
+	switch raw.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		return "number"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	default:
+		return fmt.Sprintf("%T", raw)
+	}
+}
+
+// findCycles find strongly connected components which form cycles, members of each cycle are sorted
+func findCycles(edges map[string][]string) [][]string {
+	var (
+		index   = 0
+		indexes = make(map[string]int)
+		lowLink = make(map[string]int)
+		onStack = make(map[string]bool)
+		stack   []string
+		cycles  [][]string
+	)
+
+	var connect func(name string)
+	connect = func(name string) {
+		indexes[name] = index
+		lowLink[name] = index
+		index++
+		stack = append(stack, name)
+		onStack[name] = true
+
+		selfLoop := false
+		for _, next := range edges[name] {
+			if next == name {
+				selfLoop = true
+			}
+
+			if _, visited := indexes[next]; !visited {
+				connect(next)
+				if lowLink[next] < lowLink[name] {
+					lowLink[name] = lowLink[next]
+				}
+			} else if onStack[next] && indexes[next] < lowLink[name] {
+				lowLink[name] = indexes[next]
+			}
+		}
+
+		if lowLink[name] == indexes[name] {
+			var component []string
+			for {
+				top := stack[len(stack)-1]
+				stack = stack[:len(stack)-1]
+				onStack[top] = false
+				component = append(component, top)
+
+				if top == name {
+					break
+				}
+			}
+
+			if len(component) > 1 || selfLoop {
+				sort.Strings(component)
+				cycles = append(cycles, component)
+			}
+		}
+	}
+
+	names := make([]string, 0, len(edges))
+	for name := range edges {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if _, visited := indexes[name]; !visited {
+			connect(name)
+		}
+	}
+
+	sort.Slice(cycles, func(i, j int) bool {
+		return cycles[i][0] < cycles[j][0]
+	})
+
+	return cycles
+}
@@ -10,14 +10,13 @@ type _WorkerPool struct {
 	bufQueue []*_Worker
 	qFront   int
 	qRear    int
+	drained  bool
 	mu       sync.Mutex
 }
 
 func (pool *_WorkerPool) GetWorker() (*_Worker, error) {
-	if len(pool.bufQueue) > 0 {
-		if worker := pool.qGet(); worker != nil {
-			return worker, nil
-		}
+	if worker := pool.qGet(); worker != nil {
+		return worker, nil
 	}
 
 	got := pool.Get()
@@ -31,7 +30,15 @@ func (pool *_WorkerPool) GetWorker() (*_Worker, error) {
 }
 
 func (pool *_WorkerPool) PutWorker(worker *_Worker) {
-	if len(pool.bufQueue) > 0 && pool.qPut(worker) {
+	pool.mu.Lock()
+	drained := pool.drained
+	pool.mu.Unlock()
+
+	if drained {
+		return
+	}
+
+	if pool.qPut(worker) {
 		return
 	}
 
@@ -58,11 +65,22 @@ func (pool *_WorkerPool) Warmup(size int) {
 	pool.qRear = size - 1
 }
 
+// Drain drop all buffered workers, workers in use will not be put back after Drain
+func (pool *_WorkerPool) Drain() {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+
+	pool.bufQueue = nil
+	pool.qFront = 0
+	pool.qRear = 0
+	pool.drained = true
+}
+
 func (pool *_WorkerPool) qGet() (worker *_Worker) {
 	pool.mu.Lock()
 	defer pool.mu.Unlock()
 
-	if pool.qFront == pool.qRear {
+	if len(pool.bufQueue) == 0 || pool.qFront == pool.qRear {
 		return
 	}
 
@@ -76,7 +94,7 @@ func (pool *_WorkerPool) qPut(worker *_Worker) (success bool) {
 	pool.mu.Lock()
 	defer pool.mu.Unlock()
 
-	if (pool.qRear+1)%len(pool.bufQueue) == pool.qFront {
+	if len(pool.bufQueue) == 0 || (pool.qRear+1)%len(pool.bufQueue) == pool.qFront {
 		return
 	}
 
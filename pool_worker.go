@@ -20,6 +20,11 @@ type _Worker struct {
 	StateBuilder func() State
 
 	Version string
+
+	PropsVersion int
+
+	// Parts nodes built for each vertex, including sub-nodes and wrappers, see Engine.reconfigureWorker
+	Parts map[string][]_NodePart
 }
 
 func (worker _Worker) Work(ctx context.Context) <-chan Output {
@@ -46,6 +51,9 @@ func (worker _Worker) Work(ctx context.Context) <-chan Output {
 		state = worker.StateBuilder()
 	}
 
+	nodeErrors := new(_NodeErrors)
+	ctx = context.WithValue(ctx, _NodeErrorsKey{}, nodeErrors)
+
 	// get node ready to run from a chan of works, block until all node done
 	for nodeName := range worker.Works.TODO() {
 		go func(nodeName string) {
@@ -74,6 +82,9 @@ func (worker _Worker) Work(ctx context.Context) <-chan Output {
 				if err := recover(); err != nil {
 					output.Err = fmt.Errorf("%w, node name: %s, panic info: %v", ErrWorkerPanic, nodeName, err)
 					worker.Works.Terminate(nodeName)
+				} else if err := nodeErrors.first(); err != nil {
+					output.Err = err
+					worker.Works.Terminate(nodeName)
 				} else {
 					worker.Works.Done(nodeName)
 				}
@@ -0,0 +1,126 @@
+package running
+
+import (
+	"strings"
+)
+
+// NestedProps props grouped by node path, "" for global props.
+// example: {"": {"timeout": 100}, "ClusterA": {"max_loop": 3}, "ClusterA.SubNodeB": {"key": "x"}}.
+// node inherits props of its clusters and global props, the nearest one takes effect,
+// example: SubGet("ClusterA.SubNodeB", "timeout") => 100.
+type NestedProps map[string]map[string]interface{}
+
+func (props NestedProps) Get(key string) (value interface{}, exists bool) {
+	value, exists = props[""][key]
+	return
+}
+
+func (props NestedProps) SubGet(sub, key string) (value interface{}, exists bool) {
+	path := sub
+	for {
+		if value, exists = props[path][key]; exists || path == "" {
+			return
+		}
+
+		if i := strings.LastIndex(path, "."); i >= 0 {
+			path = path[:i]
+		} else {
+			path = ""
+		}
+	}
+}
+
+func (props NestedProps) localGet(sub, key string) (value interface{}, exists bool) {
+	value, exists = props[sub][key]
+	return
+}
+
+func (props NestedProps) Copy() Props {
+	cp := make(NestedProps, len(props))
+
+	for path, values := range props {
+		cp[path] = make(map[string]interface{}, len(values))
+		for k, v := range values {
+			cp[path][k] = v
+		}
+	}
+
+	return cp
+}
+
+// Raw flatten props like StandardProps, example: {"ClusterA": {"max_loop": 3}} => {"ClusterA.max_loop": 3}.
+// inherited values are not included.
+func (props NestedProps) Raw() map[string]interface{} {
+	raw := make(map[string]interface{})
+
+	for path, values := range props {
+		for k, v := range values {
+			if path == "" {
+				raw[k] = v
+			} else {
+				raw[path+"."+k] = v
+			}
+		}
+	}
+
+	return raw
+}
+
+// Set set value of the key for node path, "" for global props
+func (props NestedProps) Set(path, key string, value interface{}) {
+	if props[path] == nil {
+		props[path] = make(map[string]interface{})
+	}
+
+	props[path][key] = value
+}
+
+// applyPropsOps same as applyPropsOps of flat props, keys of added props are split at the last dot
+func (props NestedProps) applyPropsOps(ops []_PropsOp) {
+	for _, op := range ops {
+		if op.Props != nil {
+			for key, value := range op.Props {
+				path := ""
+				if i := strings.LastIndex(key, "."); i >= 0 {
+					path, key = key[:i], key[i+1:]
+				}
+
+				if _, ok := props[path][key]; !ok {
+					props.Set(path, key, value)
+				}
+			}
+			continue
+		}
+
+		renamed := make(NestedProps)
+
+		for path, values := range props {
+			if path == "" {
+				continue
+			}
+
+			segments := strings.Split(path, ".")
+			matched := false
+
+			for i := range segments {
+				if segments[i] == op.From {
+					segments[i] = op.To
+					matched = true
+				}
+			}
+
+			if matched {
+				delete(props, path)
+				if op.To != "" {
+					renamed[strings.Join(segments, ".")] = values
+				}
+			}
+		}
+
+		for path, values := range renamed {
+			for k, v := range values {
+				props.Set(path, k, v)
+			}
+		}
+	}
+}
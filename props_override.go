@@ -0,0 +1,108 @@
+package running
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// overrideWorker return a copy of worker, vertexes affected by props override are rebuilt without prebuilt nodes
+func (engine *Engine) overrideWorker(plan *Plan, worker *_Worker, override NestedProps) (*_Worker, error) {
+	plan.locker.RLock()
+	defer plan.locker.RUnlock()
+
+	affected := make(map[string]bool)
+	for path := range override {
+		if path == "" {
+			for name := range plan.graph.Vertexes {
+				affected[name] = true
+			}
+			continue
+		}
+
+		name := path
+		if i := strings.Index(path, "."); i >= 0 {
+			name = path[:i]
+		}
+
+		if plan.graph.Vertexes[name] == nil {
+			return nil, fmt.Errorf("props override of %s failed, vertex %s not found", path, name)
+		}
+		affected[name] = true
+	}
+
+	props, groups, err := engine.resolvePlanProps(plan)
+	if err != nil {
+		return nil, err
+	}
+
+	resolved, err := engine.resolveProps(override)
+	if err != nil {
+		return nil, err
+	}
+	props = _OverrideProps{Props: props, Override: resolved}
+
+	names := make([]string, 0, len(affected))
+	for name := range affected {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	nodes := make(map[string]Node, len(worker.Nodes))
+	for name, node := range worker.Nodes {
+		nodes[name] = node
+	}
+
+	for _, name := range names {
+		if plan.graph.Vertexes[name].RefRoot.Virtual {
+			continue
+		}
+
+		if nodes[name], err = engine.buildNode(plan, props, groups, nil, name, "", make(map[string]Node), nil); err != nil {
+			return nil, err
+		}
+	}
+
+	return &_Worker{
+		Works:        worker.Works,
+		Nodes:        nodes,
+		StateBuilder: worker.StateBuilder,
+		Version:      worker.Version,
+		PropsVersion: worker.PropsVersion,
+	}, nil
+}
+
+// _OverrideProps lookup override props before the original props
+type _OverrideProps struct {
+	Props
+
+	Override Props
+}
+
+func (props _OverrideProps) Get(key string) (value interface{}, exists bool) {
+	if value, exists = props.Override.Get(key); exists {
+		return
+	}
+
+	return props.Props.Get(key)
+}
+
+func (props _OverrideProps) SubGet(sub, key string) (value interface{}, exists bool) {
+	if value, exists = props.Override.SubGet(sub, key); exists {
+		return
+	}
+
+	return props.Props.SubGet(sub, key)
+}
+
+func (props _OverrideProps) localGet(sub, key string) (value interface{}, exists bool) {
+	if value, exists = localGet(props.Override, sub, key); exists {
+		return
+	}
+
+	return localGet(props.Props, sub, key)
+}
+
+func (props _OverrideProps) Copy() Props {
+	return _OverrideProps{Props: props.Props.Copy(), Override: props.Override.Copy()}
+}
@@ -0,0 +1,122 @@
+package running
+
+import (
+	"fmt"
+)
+
+// UpdatePlanProps replace props of plan register in engine without rebuilding the graph.
+// pooled workers are updated on next checkout, nodes implement Reconfigurable are reconfigured in place,
+// other nodes are rebuilt. nodes cached by ReUseNodes are dropped.
+func (engine *Engine) UpdatePlanProps(name string, props Props) error {
+	engine.plansLocker.RLock()
+	plan := engine.plans[name]
+	engine.plansLocker.RUnlock()
+
+	if plan == nil {
+		return fmt.Errorf("%w, plan: %s", ErrPlanNotFound, name)
+	}
+
+	plan.locker.Lock()
+
+	newProps, ok := plan.graph.initProps(props)
+	if !ok {
+		plan.locker.Unlock()
+		return fmt.Errorf("failed to update props of plan %s, props keys of renamed, removed or included nodes can not be updated, props not exportable", name)
+	}
+
+	engine.buildersLocker.RLock()
+	err := engine.checkPlanProps(plan.graph, newProps)
+	engine.buildersLocker.RUnlock()
+
+	if err == nil {
+		plan.Props = props
+		plan.props = newProps
+		plan.propsVersion++
+		err = plan.initPrebuilt()
+	}
+
+	plan.locker.Unlock()
+
+	if err != nil {
+		return err
+	}
+
+	return engine.resolveDependants(name)
+}
+
+// reconfigureWorker apply props updated by UpdatePlanProps to worker of the same plan version.
+// vertex is reconfigured in place if all nodes of it, including sub-nodes and wrappers, implement Reconfigurable,
+// otherwise the vertex is rebuilt. vertex with nodes cloned from prebuilt nodes is always rebuilt.
+func (engine *Engine) reconfigureWorker(plan *Plan, worker *_Worker) error {
+	plan.locker.RLock()
+	defer plan.locker.RUnlock()
+
+	if worker.Version != plan.version || worker.PropsVersion == plan.propsVersion {
+		return nil
+	}
+
+	props, groups, err := engine.resolvePlanProps(plan)
+	if err != nil {
+		return err
+	}
+
+	var rebuild []string
+
+	engine.buildersLocker.RLock()
+	for name, vertex := range plan.graph.Vertexes {
+		ref := vertex.RefRoot
+		if ref.Virtual {
+			continue
+		}
+
+		if !engine.reconfigureParts(groups, props, worker.Parts[name]) {
+			rebuild = append(rebuild, name)
+		}
+	}
+	engine.buildersLocker.RUnlock()
+
+	if worker.Parts == nil {
+		worker.Parts = make(map[string][]_NodePart)
+	}
+
+	for _, name := range rebuild {
+		var parts []_NodePart
+		node, err := engine.buildNode(plan, props, groups, plan.prebuilt, name, "", make(map[string]Node), &parts)
+		if err != nil {
+			return err
+		}
+
+		worker.Nodes[name] = node
+		worker.Parts[name] = parts
+	}
+
+	worker.PropsVersion = plan.propsVersion
+	return nil
+}
+
+// reconfigureParts reconfigure nodes of a vertex, return false if any of them can not be reconfigured.
+// caller should hold the builders lock.
+func (engine *Engine) reconfigureParts(groups []_PropsGroup, props Props, parts []_NodePart) bool {
+	if len(parts) == 0 {
+		return false
+	}
+
+	for _, part := range parts {
+		if part.Prebuilt {
+			return false
+		}
+
+		if _, ok := part.Node.(Reconfigurable); !ok {
+			return false
+		}
+	}
+
+	for _, part := range parts {
+		nodeProps := engine.withPropsDefaults(withPropsGroups(groups, props, part.Path, part.Ref), part.Path, part.Builder)
+		if err := part.Node.(Reconfigurable).Reconfigure(nodeProps); err != nil {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,278 @@
+package running
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// SecretMask replace secret props value when plan is exported or described
+const SecretMask = "******"
+
+// Secret props value which is masked when plan is exported or described, nodes get it as string
+type Secret string
+
+func (secret Secret) MarshalJSON() ([]byte, error) {
+	return json.Marshal(SecretMask)
+}
+
+func (secret Secret) String() string {
+	return SecretMask
+}
+
+func (secret Secret) GoString() string {
+	return SecretMask
+}
+
+// PropsResolveFunc resolve reference in props value, example: ${secret:db_password} => kind "secret", ref "db_password"
+type PropsResolveFunc func(kind, ref string) (interface{}, error)
+
+var propsRefRegexp = regexp.MustCompile(`\$\{([a-z]+):([^}]*)\}`)
+
+// DefaultPropsResolver resolve ${env:NAME} as environment variable and ${file:/path} as file content
+// without trailing newline, secrets need a resolver set to Engine.PropsResolver.
+func DefaultPropsResolver(kind, ref string) (interface{}, error) {
+	switch kind {
+	case "env":
+		if value, ok := os.LookupEnv(ref); ok {
+			return value, nil
+		}
+		return nil, fmt.Errorf("env %s not set", ref)
+	case "file":
+		data, err := os.ReadFile(ref)
+		if err != nil {
+			return nil, err
+		}
+		return strings.TrimSuffix(string(data), "\n"), nil
+	case "secret":
+		return nil, fmt.Errorf("no resolver for secret %s", ref)
+	default:
+		return nil, fmt.Errorf("unknown props reference kind %s", kind)
+	}
+}
+
+// isPropsRef check if props value is a string with references
+func isPropsRef(value interface{}) bool {
+	s, ok := value.(string)
+	return ok && propsRefRegexp.MatchString(s)
+}
+
+// maskProp replace secret value with SecretMask
+func maskProp(value interface{}) interface{} {
+	if _, ok := value.(Secret); ok {
+		return SecretMask
+	}
+
+	return value
+}
+
+// resolveProps resolve references and unwrap secrets in props values when build worker.
+// a string which is exactly a reference is replaced by the resolved value, otherwise the value is formatted into the string.
+// only exportable props are resolved.
+func (engine *Engine) resolveProps(props Props) (Props, error) {
+	resolve := engine.PropsResolver
+	if resolve == nil {
+		resolve = DefaultPropsResolver
+	}
+
+	if nested, ok := props.(NestedProps); ok {
+		// resolve in copy, so sub-nodes inherit resolved values
+		var cp NestedProps
+		for path, values := range nested {
+			for key, value := range values {
+				result, changed, err := resolvePropValue(resolve, value)
+				if err != nil {
+					return nil, fmt.Errorf("failed to resolve props %s of %q, %w", key, path, err)
+				}
+
+				if changed {
+					if cp == nil {
+						cp = nested.Copy().(NestedProps)
+					}
+					cp[path][key] = result
+				}
+			}
+		}
+
+		if cp == nil {
+			return props, nil
+		}
+
+		return cp, nil
+	}
+
+	exportable, ok := props.(ExportableProps)
+	if !ok {
+		return props, nil
+	}
+
+	resolved := make(map[string]interface{})
+
+	for key, value := range exportable.Raw() {
+		result, changed, err := resolvePropValue(resolve, value)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve props %s, %w", key, err)
+		}
+
+		if changed {
+			resolved[key] = result
+		}
+	}
+
+	if len(resolved) == 0 {
+		return props, nil
+	}
+
+	return _ResolvedProps{Props: props, Resolved: resolved}, nil
+}
+
+// resolvePlanProps resolve props and values of props groups of the plan, caller should hold the plan lock
+func (engine *Engine) resolvePlanProps(plan *Plan) (Props, []_PropsGroup, error) {
+	var props Props = EmptyProps{}
+	if plan.props != nil {
+		var err error
+		if props, err = engine.resolveProps(plan.props); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	groups, err := engine.resolvePropsGroups(plan.graph.PropsGroups)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return props, groups, nil
+}
+
+// resolvePropsGroups resolve references and unwrap secrets in values of props groups,
+// groups are copied if any value is changed.
+func (engine *Engine) resolvePropsGroups(groups []_PropsGroup) ([]_PropsGroup, error) {
+	resolve := engine.PropsResolver
+	if resolve == nil {
+		resolve = DefaultPropsResolver
+	}
+
+	var resolved []_PropsGroup
+	for i, group := range groups {
+		var values map[string]interface{}
+
+		for key, value := range group.Props {
+			result, changed, err := resolvePropValue(resolve, value)
+			if err != nil {
+				target := "label " + group.Label
+				if group.Type != "" {
+					target = "type " + group.Type
+				}
+				return nil, fmt.Errorf("failed to resolve props %s of %s, %w", key, target, err)
+			}
+
+			if changed {
+				if values == nil {
+					values = make(map[string]interface{}, len(group.Props))
+					for k, v := range group.Props {
+						values[k] = v
+					}
+				}
+				values[key] = result
+			}
+		}
+
+		if values != nil {
+			if resolved == nil {
+				resolved = append([]_PropsGroup(nil), groups...)
+			}
+			resolved[i] = _PropsGroup{Label: group.Label, Type: group.Type, Props: values}
+		}
+	}
+
+	if resolved == nil {
+		return groups, nil
+	}
+
+	return resolved, nil
+}
+
+// resolvePropValue resolve references in string value and unwrap secret, return false if value is not changed
+func resolvePropValue(resolve PropsResolveFunc, value interface{}) (interface{}, bool, error) {
+	switch v := value.(type) {
+	case Secret:
+		return string(v), true, nil
+	case string:
+		matches := propsRefRegexp.FindAllStringSubmatchIndex(v, -1)
+		if len(matches) == 0 {
+			return value, false, nil
+		}
+
+		if len(matches) == 1 && matches[0][0] == 0 && matches[0][1] == len(v) {
+			result, err := resolve(v[matches[0][2]:matches[0][3]], v[matches[0][4]:matches[0][5]])
+			if err != nil {
+				return nil, false, err
+			}
+
+			if secret, ok := result.(Secret); ok {
+				result = string(secret)
+			}
+
+			return result, true, nil
+		}
+
+		var sb strings.Builder
+		last := 0
+		for _, match := range matches {
+			result, err := resolve(v[match[2]:match[3]], v[match[4]:match[5]])
+			if err != nil {
+				return nil, false, err
+			}
+
+			sb.WriteString(v[last:match[0]])
+			if secret, ok := result.(Secret); ok {
+				sb.WriteString(string(secret))
+			} else {
+				sb.WriteString(fmt.Sprint(result))
+			}
+			last = match[1]
+		}
+		sb.WriteString(v[last:])
+
+		return sb.String(), true, nil
+	default:
+		return value, false, nil
+	}
+}
+
+// _ResolvedProps lookup resolved values before the original props
+type _ResolvedProps struct {
+	Props
+
+	Resolved map[string]interface{}
+}
+
+func (props _ResolvedProps) Get(key string) (value interface{}, exists bool) {
+	if value, exists = props.Resolved[key]; exists {
+		return
+	}
+
+	return props.Props.Get(key)
+}
+
+func (props _ResolvedProps) SubGet(sub, key string) (value interface{}, exists bool) {
+	if value, exists = props.Resolved[sub+"."+key]; exists {
+		return
+	}
+
+	return props.Props.SubGet(sub, key)
+}
+
+func (props _ResolvedProps) localGet(sub, key string) (value interface{}, exists bool) {
+	if value, exists = props.Resolved[sub+"."+key]; exists {
+		return
+	}
+
+	return localGet(props.Props, sub, key)
+}
+
+func (props _ResolvedProps) Copy() Props {
+	return _ResolvedProps{Props: props.Props.Copy(), Resolved: props.Resolved}
+}
@@ -0,0 +1,200 @@
+package running
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+)
+
+const (
+	PropTypeAny    = "any"
+	PropTypeString = "string"
+	PropTypeInt    = "int"
+	PropTypeFloat  = "float"
+	PropTypeBool   = "bool"
+)
+
+// PropSchema declare a prop of node builder.
+// Type is one of PropTypeAny, PropTypeString, PropTypeInt, PropTypeFloat, PropTypeBool,
+// other type names are only descriptive and not validated.
+type PropSchema struct {
+	Name string
+
+	Type string
+
+	Default interface{}
+
+	Required bool
+
+	Description string
+}
+
+// PropsError a prop of node does not match the props schema of its builder
+type PropsError struct {
+	NodePath string
+
+	Prop string
+
+	Msg string
+}
+
+func (err PropsError) Error() string {
+	return fmt.Sprintf("%s.%s: %s", err.NodePath, err.Prop, err.Msg)
+}
+
+// PropsErrors all props errors of plan
+type PropsErrors []PropsError
+
+func (errs PropsErrors) Error() string {
+	msgs := make([]string, 0, len(errs))
+	for _, err := range errs {
+		msgs = append(msgs, err.Error())
+	}
+
+	return fmt.Sprintf("invalid props, %s", strings.Join(msgs, "; "))
+}
+
+// CheckPropValue check if value matches the prop type
+func CheckPropValue(typ string, value interface{}) bool {
+	v := reflect.ValueOf(value)
+
+	switch typ {
+	case PropTypeString:
+		return v.Kind() == reflect.String
+	case PropTypeBool:
+		return v.Kind() == reflect.Bool
+	case PropTypeInt:
+		switch v.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			return true
+		case reflect.Float32, reflect.Float64:
+			// numbers loaded from json are float64
+			return v.Float() == math.Trunc(v.Float())
+		default:
+			return false
+		}
+	case PropTypeFloat:
+		switch v.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+			reflect.Float32, reflect.Float64:
+			return true
+		default:
+			return false
+		}
+	default:
+		return true
+	}
+}
+
+// validatePlanProps check props of plan nodes against props schema of their builders
+func (engine *Engine) validatePlanProps(plan *Plan) error {
+	plan.locker.RLock()
+	defer plan.locker.RUnlock()
+
+	engine.buildersLocker.RLock()
+	defer engine.buildersLocker.RUnlock()
+
+	return engine.checkPlanProps(plan.graph, plan.props)
+}
+
+// checkPlanProps check props against props schema of builders of graph nodes,
+// caller should hold the plan lock and the builders lock.
+func (engine *Engine) checkPlanProps(graph *_DAG, props Props) error {
+	if props == nil {
+		props = EmptyProps{}
+	}
+
+	var errs PropsErrors
+
+	check := func(nodePath string, ref *_NodeRef, builder string) {
+		nodeProps := graph.withGroupProps(props, nodePath, ref)
+
+		for _, schema := range engine.buildersProps[builder] {
+			value, ok := nodeProps.SubGet(nodePath, schema.Name)
+			if !ok {
+				if schema.Required {
+					errs = append(errs, PropsError{NodePath: nodePath, Prop: schema.Name,
+						Msg: fmt.Sprintf("required by %s but not set", builder)})
+				}
+				continue
+			}
+
+			// references are resolved when build worker
+			if !isPropsRef(value) && !CheckPropValue(schema.Type, value) {
+				errs = append(errs, PropsError{NodePath: nodePath, Prop: schema.Name,
+					Msg: fmt.Sprintf("%s expect %s, got %T", builder, schema.Type, value)})
+			}
+		}
+	}
+
+	graph.WalkNodes(func(nodePath string, ref *_NodeRef) {
+		if ref.Virtual {
+			return
+		}
+
+		check(nodePath, ref, ref.NodeType)
+		for _, wrapper := range ref.Wrappers {
+			check(nodePath, ref, wrapper)
+		}
+	})
+
+	if len(errs) > 0 {
+		return errs
+	}
+
+	return nil
+}
+
+// withPropsDefaults return props with defaults of the props schema for the node.
+// caller should hold the builders lock.
+func (engine *Engine) withPropsDefaults(props Props, nodePath, builder string) Props {
+	var defaults map[string]interface{}
+
+	for _, schema := range engine.buildersProps[builder] {
+		if schema.Default != nil {
+			if defaults == nil {
+				defaults = make(map[string]interface{})
+			}
+			defaults[schema.Name] = schema.Default
+		}
+	}
+
+	if defaults == nil {
+		return props
+	}
+
+	return _DefaultProps{Props: props, NodePath: nodePath, Defaults: defaults}
+}
+
+// _DefaultProps lookup defaults of the node when prop is not set
+type _DefaultProps struct {
+	Props
+
+	NodePath string
+
+	Defaults map[string]interface{}
+}
+
+func (props _DefaultProps) SubGet(sub, key string) (value interface{}, exists bool) {
+	if value, exists = props.Props.SubGet(sub, key); exists {
+		return
+	}
+
+	if sub == props.NodePath {
+		value, exists = props.Defaults[key]
+	}
+
+	return
+}
+
+// localGet defaults are not included, they are lookup after inherited values
+func (props _DefaultProps) localGet(sub, key string) (value interface{}, exists bool) {
+	return localGet(props.Props, sub, key)
+}
+
+func (props _DefaultProps) Copy() Props {
+	return _DefaultProps{Props: props.Props.Copy(), NodePath: props.NodePath, Defaults: props.Defaults}
+}
@@ -0,0 +1,102 @@
+package test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/symphony09/running"
+)
+
+func TestDiffPlans(t *testing.T) {
+	planA := running.NewPlan(running.StandardProps{"p": 1, "L1.max_loop": 5, "L1.B1.key": "a"}, nil,
+		running.AddNodes("Loop", "L1"),
+		running.AddNodes("BaseTest", "B1", "B2", "B3"),
+		running.MergeNodes("L1", "B1"),
+		running.MarkNodes("old", "B2"),
+		running.SLinkNodes("L1", "B2", "B3"))
+
+	planB := running.NewPlan(running.StandardProps{"p": 2, "L1.max_loop": 5, "L1.B1.key": "b"}, nil,
+		running.AddNodes("Loop", "L1"),
+		running.AddNodes("BaseTest", "B1"),
+		running.AddNodes("Nothing", "B2"),
+		running.AddNodes("BaseTest", "B4"),
+		running.MergeNodes("L1", "B1"),
+		running.WrapNodes("Debug", "B2"),
+		running.MarkNodes("new", "B2"),
+		running.ReUseNodes("B2"),
+		running.SLinkNodes("L1", "B2", "B4"))
+
+	diff, err := running.DiffPlans(planA, planB)
+	if err != nil {
+		t.Errorf("diff plans failed, err=%s", err.Error())
+		return
+	}
+
+	expect := `+ vertex B4
+- vertex B3
++ edge B2 -> B4
+- edge B2 -> B3
+~ global props p: 1 -> 2
+~ node B2 type: BaseTest -> Nothing
+~ node B2 wrappers: [] -> [Debug]
++ node B2 label: new
+- node B2 label: old
+~ node B2 reuse: false -> true
+- node B3: BaseTest
++ node B4: BaseTest
+~ node L1.B1 props key: a -> b`
+
+	if diff.String() != expect {
+		t.Errorf("wrong diff, expect:\n%s\ngot:\n%s", expect, diff.String())
+	}
+
+	e := running.NewDefaultEngine()
+	if err = e.RegisterPlan("TestDiffPlans", planA); err != nil {
+		t.Errorf("register plan failed, err=%s", err.Error())
+		return
+	}
+
+	data, err := json.Marshal(planA)
+	if err != nil {
+		t.Errorf("marshal plan failed, err=%s", err.Error())
+		return
+	}
+
+	if diff, err = running.Inspect(e).DiffPlan("TestDiffPlans", data); err != nil {
+		t.Errorf("diff plan failed, err=%s", err.Error())
+	} else if !diff.Empty() {
+		t.Errorf("expect no diff after json round trip, got:\n%s", diff.String())
+	}
+}
+
+func TestDiffPlansBaseAndGroups(t *testing.T) {
+	planA := running.NewPlan(nil, nil,
+		running.AddNodes("BaseTest", "B1"),
+		running.MarkNodes("db", "B1"),
+		running.PropsForLabel("db", map[string]interface{}{"timeout": 1, "retries": 3}),
+		running.LinkNodes("B1"))
+	planA.Base = "BaseA"
+
+	planB := running.NewPlan(nil, nil,
+		running.AddNodes("BaseTest", "B1"),
+		running.MarkNodes("db", "B1"),
+		running.PropsForLabel("db", map[string]interface{}{"timeout": 2}),
+		running.PropsForLabel("db", map[string]interface{}{"retries": 3}),
+		running.PropsForType("BaseTest", map[string]interface{}{"key": "x"}),
+		running.LinkNodes("B1"))
+	planB.Base = "BaseB"
+
+	diff, err := running.DiffPlans(planA, planB)
+	if err != nil {
+		t.Errorf("diff plans failed, err=%s", err.Error())
+		return
+	}
+
+	expect := `~ base: BaseA -> BaseB
++ props group type BaseTest key: x
+~ props group label db timeout: 1 -> 2`
+
+	if diff.String() != expect {
+		t.Errorf("expect diff:\n%s\nbut got:\n%s", expect, diff.String())
+	}
+}
@@ -0,0 +1,211 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/symphony09/running"
+	"github.com/symphony09/running/utils"
+)
+
+func TestPlanDSL(t *testing.T) {
+	src := `
+# sub-node shared by loop clusters
+node B1:BaseTest
+
+L1:Loop{B1} -> S1:SetState @set -> L2:Loop{B1}
+wrap TimerWrapper(S1)
+reuse S1
+`
+
+	props := running.StandardProps(map[string]interface{}{
+		"L1.max_loop": 5,
+		"L2.max_loop": 5,
+		"L1.watch":    "loop?",
+		"L2.watch":    "loop?",
+		"S1.key":      "loop?",
+		"S1.value":    true,
+	})
+
+	plan, err := running.NewPlanFromDSL(props, nil, src)
+	if err != nil {
+		t.Errorf("parse plan dsl failed, err=%s", err.Error())
+		return
+	}
+
+	if err = running.RegisterPlan("TestPlanDSL", plan); err != nil {
+		t.Errorf("register plan failed, err=%s", err.Error())
+		return
+	}
+
+	output := <-running.ExecPlan("TestPlanDSL", context.Background())
+	if output.Err != nil {
+		t.Errorf("exec plan failed, err=%s", output.Err.Error())
+		return
+	}
+
+	sum := utils.GetRunSummary(output.State)
+	if len(sum.Logs["L1.B1"]) != 0 || len(sum.Logs["L2.B1"]) != 5 {
+		t.Errorf("expect L1.B1 run 0 times and L2.B1 run 5 times, but got %d and %d",
+			len(sum.Logs["L1.B1"]), len(sum.Logs["L2.B1"]))
+	}
+
+	expect := `node B1:BaseTest
+node L1:Loop{B1}
+node L2:Loop{B1}
+node S1:SetState @set
+wrap TimerWrapper(S1)
+reuse S1
+L1 -> S1
+L2
+S1 -> L2
+`
+
+	printed, err := running.ExportPlanDSL("TestPlanDSL")
+	if err != nil {
+		t.Errorf("export plan dsl failed, err=%s", err.Error())
+		return
+	}
+
+	if printed != expect {
+		t.Errorf("wrong plan dsl, expect:\n%s\ngot:\n%s", expect, printed)
+		return
+	}
+
+	reparsed, err := running.NewPlanFromDSL(props, nil, printed)
+	if err != nil {
+		t.Errorf("parse printed plan dsl failed, err=%s", err.Error())
+		return
+	}
+
+	if again, _ := running.PrintPlanDSL(reparsed); again != printed {
+		t.Errorf("expect same plan dsl after round trip, got:\n%s", again)
+	}
+}
+
+func TestPlanDSLError(t *testing.T) {
+	cases := []struct {
+		src          string
+		line, column int
+	}{
+		{"A:T ->\n  B:T -> ", 2, 10},
+		{"node A", 1, 6},
+		{"A:T\nwrap Debug(A", 2, 13},
+		{"A:T -> B:T\nC:{D}", 2, 3},
+		{"A:T % B", 1, 5},
+		{"A:T1 -> B:T2\nB -> A:T3", 2, 6},
+		{"virtual V\nV:T -> A:T", 2, 1},
+		{"\"A -> B:T", 1, 1},
+	}
+
+	for _, c := range cases {
+		_, err := running.ParsePlanDSL(c.src)
+
+		var dslErr *running.DSLError
+		if !errors.As(err, &dslErr) {
+			t.Errorf("expect dsl error for %q, but got %v", c.src, err)
+			continue
+		}
+
+		if dslErr.Line != c.line || dslErr.Column != c.column {
+			t.Errorf("expect error at line %d column %d for %q, but got %s", c.line, c.column, c.src, dslErr)
+		}
+	}
+}
+
+func TestPlanDSLQuoted(t *testing.T) {
+	plan := running.NewPlan(nil, nil,
+		running.AddNodes("Base-Test", "my-node", "node"),
+		running.MarkNodes("a label", "my-node"),
+		running.WrapNodes("Debug", "my-node"),
+		running.LinkNodes("my-node", "node"))
+
+	printed, err := running.PrintPlanDSL(plan)
+	if err != nil {
+		t.Errorf("print plan dsl failed, err=%s", err.Error())
+		return
+	}
+
+	if !strings.Contains(printed, `node "my-node":"Base-Test" @"a label"`) {
+		t.Errorf("expect quoted identifiers, got:\n%s", printed)
+	}
+
+	reparsed, err := running.NewPlanFromDSL(nil, nil, printed)
+	if err != nil {
+		t.Errorf("parse printed plan dsl failed, err=%s", err.Error())
+		return
+	}
+
+	if again, _ := running.PrintPlanDSL(reparsed); again != printed {
+		t.Errorf("expect same plan dsl after round trip, got:\n%s", again)
+	}
+}
+
+func TestPlanDSLPropsGroups(t *testing.T) {
+	src := `S1:SetState @"my label" -> S2:SetState
+props SetState {
+	"value": "type",
+	"retry": 3
+}
+props @"my label" {"value": "label"}
+`
+
+	plan, err := running.NewPlanFromDSL(running.StandardProps{"S1.key": "k1", "S2.key": "k2"}, nil, src)
+	if err != nil {
+		t.Errorf("parse plan dsl failed, err=%s", err.Error())
+		return
+	}
+
+	expect := `node S1:SetState @"my label"
+node S2:SetState
+props SetState {"retry":3,"value":"type"}
+props @"my label" {"value":"label"}
+S1 -> S2
+S2
+`
+
+	printed, err := running.PrintPlanDSL(plan)
+	if err != nil {
+		t.Errorf("print plan dsl failed, err=%s", err.Error())
+		return
+	}
+
+	if printed != expect {
+		t.Errorf("wrong plan dsl, expect:\n%s\ngot:\n%s", expect, printed)
+		return
+	}
+
+	if err = running.RegisterPlan("TestPlanDSLPropsGroups", plan); err != nil {
+		t.Errorf("register plan failed, err=%s", err.Error())
+		return
+	}
+
+	output := <-running.ExecPlan("TestPlanDSLPropsGroups", context.Background())
+	if output.Err != nil {
+		t.Errorf("exec plan failed, err=%s", output.Err.Error())
+		return
+	}
+
+	for key, expect := range map[string]string{"k1": "label", "k2": "type"} {
+		if v, _ := output.State.Query(key); v != expect {
+			t.Errorf("expect %s=%s, got %v", key, expect, v)
+		}
+	}
+
+	for _, c := range []struct {
+		src          string
+		line, column int
+	}{
+		{"props @l {\"value\": }", 1, 10},
+		{"props @l [1]", 1, 10},
+		{"props T\n{}", 1, 8},
+	} {
+		_, err = running.ParsePlanDSL(c.src)
+		var dslErr *running.DSLError
+		if !errors.As(err, &dslErr) || dslErr.Line != c.line || dslErr.Column != c.column {
+			t.Errorf("expect error at %d:%d for %q, got %v", c.line, c.column, c.src, err)
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/symphony09/running"
+	"github.com/symphony09/running/common"
+	"github.com/symphony09/running/utils"
+)
+
+type GreetIn struct {
+	Greeting string `running:"prop:greeting;default:Hello"`
+
+	Name string `running:"in:name"`
+
+	Times int `running:"in:times;optional"`
+}
+
+type GreetOut struct {
+	Message string `running:"out:message"`
+}
+
+func TestFuncNode(t *testing.T) {
+	e := running.NewDefaultEngine()
+	e.RegisterNodeBuilder("Greet", common.NewFuncNodeBuilder(func(ctx context.Context, in GreetIn) (*GreetOut, error) {
+		if in.Name == "" {
+			return nil, errors.New("empty name")
+		}
+
+		return &GreetOut{Message: fmt.Sprintf("%s, %s%s", in.Greeting, in.Name, strings.Repeat("!", in.Times))}, nil
+	}))
+	e.RegisterNodeBuilder("Invalid", common.NewFuncNodeBuilder(func(in GreetIn) GreetOut { return GreetOut{} }))
+
+	plan := running.NewPlan(running.StandardProps{"G.greeting": "Hi"}, nil,
+		running.AddNodes("Greet", "G"),
+		running.LinkNodes("G"))
+
+	if err := e.RegisterPlan("TestFuncNode", plan); err != nil {
+		t.Errorf("register plan failed, err=%s", err.Error())
+		return
+	}
+
+	exec := func(values map[string]interface{}) running.Output {
+		state := running.NewStandardState()
+		for k, v := range values {
+			state.Update(k, v)
+		}
+
+		ctx := context.WithValue(context.Background(), running.CtxKey, running.CtxParams{State: state})
+		return <-e.ExecPlan("TestFuncNode", ctx)
+	}
+
+	output := exec(map[string]interface{}{"name": "Oliver", "times": float64(2)})
+	if output.Err != nil {
+		t.Errorf("exec plan failed, err=%s", output.Err.Error())
+	} else if message, _ := output.State.Query("message"); message != "Hi, Oliver!!" {
+		t.Errorf("expect message Hi, Oliver!!, got %v", message)
+	}
+
+	// errors are reported as output error instead of panic
+	output = exec(map[string]interface{}{"name": ""})
+	var nodeErr *running.NodeError
+	if !errors.As(output.Err, &nodeErr) || nodeErr.NodeName != "G" || !strings.Contains(output.Err.Error(), "empty name") {
+		t.Errorf("expect error returned by func, got %v", output.Err)
+	} else if _, ok := output.State.Query("message"); ok {
+		t.Errorf("expect no output when func returns error")
+	}
+
+	output = exec(nil)
+	if !errors.Is(output.Err, utils.ErrStateKeyNotFound) {
+		t.Errorf("expect state key not found, got %v", output.Err)
+	}
+
+	// clusters run sub-nodes without recover, errors must not panic
+	e.RegisterNodeBuilder("Loop", common.NewLoopCluster)
+	plan = running.NewPlan(running.StandardProps{"L.max_loop": 1}, nil,
+		running.AddNodes("Greet", "G"),
+		running.AddNodes("Loop", "L"),
+		running.MergeNodes("L", "G"),
+		running.LinkNodes("L"))
+	if err := e.RegisterPlan("TestFuncNode", plan); err != nil {
+		t.Errorf("register plan failed, err=%s", err.Error())
+		return
+	}
+	e.ClearPool("TestFuncNode")
+
+	output = exec(map[string]interface{}{"name": ""})
+	if !errors.As(output.Err, &nodeErr) || nodeErr.NodeName != "L.G" {
+		t.Errorf("expect error of sub-node reported, got %v", output.Err)
+	}
+
+	plan = running.NewPlan(nil, nil, running.AddNodes("Invalid", "I"), running.LinkNodes("I"))
+	if err := e.RegisterPlan("TestFuncNodeInvalid", plan); err != nil {
+		t.Errorf("register plan failed, err=%s", err.Error())
+		return
+	}
+
+	if output = <-e.ExecPlan("TestFuncNodeInvalid", context.Background()); !errors.Is(output.Err, running.ErrBuildWorkerFailed) {
+		t.Errorf("expect build worker failed, got %v", output.Err)
+	}
+}
@@ -0,0 +1,178 @@
+package test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/symphony09/running"
+)
+
+func TestIncludePlan(t *testing.T) {
+	stage := running.NewPlan(running.StandardProps{
+		"S1.key":      "k1",
+		"S1.value":    "stage",
+		"L1.max_loop": 1,
+		"L1.S2.key":   "k2",
+		"L1.S2.value": "stage",
+	}, nil,
+		running.AddNodes("SetState", "S1", "S2"),
+		running.AddNodes("Loop", "L1"),
+		running.MergeNodes("L1", "S2"),
+		running.LinkNodes("S1", "L1"))
+
+	if err := running.RegisterPlan("TestIncludePlanStage", stage); err != nil {
+		t.Errorf("register plan failed, err=%s", err.Error())
+		return
+	}
+
+	plan := running.NewPlan(running.StandardProps{
+		"Start.key":     "k0",
+		"Start.value":   "main",
+		"End.key":       "k3",
+		"End.value":     "main",
+		"E_S1.value":    "override",
+		"Start.ignored": true,
+	}, nil,
+		running.AddNodes("SetState", "Start", "End"),
+		running.IncludePlan("TestIncludePlanStage", "E_",
+			running.IncludeAfter("Start"), running.IncludeBefore("End")))
+
+	if err := running.RegisterPlan("TestIncludePlan", plan); err != nil {
+		t.Errorf("register plan failed, err=%s", err.Error())
+		return
+	}
+
+	info := running.Inspect(running.Global).DescribePlan("TestIncludePlan")
+	if len(info.Vertexes) != 4 {
+		t.Errorf("expect 4 vertexes, got %d", len(info.Vertexes))
+	}
+
+	edges := make(map[string]bool)
+	for _, edge := range info.Edges {
+		edges[edge.From+"->"+edge.To] = true
+	}
+
+	for _, edge := range []string{"Start->E_S1", "E_S1->E_L1", "E_L1->End"} {
+		if !edges[edge] {
+			t.Errorf("expect edge %s, got %v", edge, info.Edges)
+		}
+	}
+
+	output := <-running.ExecPlan("TestIncludePlan", context.Background())
+	if output.Err != nil {
+		t.Errorf("exec plan failed, err=%s", output.Err.Error())
+		return
+	}
+
+	for key, expect := range map[string]string{"k0": "main", "k1": "override", "k2": "stage", "k3": "main"} {
+		if v, _ := output.State.Query(key); v != expect {
+			t.Errorf("expect %s=%s, got %v", key, expect, v)
+		}
+	}
+
+	conflict := running.NewPlan(nil, nil,
+		running.AddNodes("SetState", "E_S1"),
+		running.IncludePlan("TestIncludePlanStage", "E_"),
+		running.IncludePlan("NotExist", "N_"))
+
+	if err := conflict.Init(); err != nil {
+		t.Errorf("init plan failed, err=%s", err.Error())
+		return
+	}
+
+	stageInfo := running.Inspect(running.Global).DescribePlan("TestIncludePlanStage")
+	if len(stageInfo.Vertexes) != 2 {
+		t.Errorf("included plan should not be changed, got %d vertexes", len(stageInfo.Vertexes))
+	}
+
+	conflict.Strict = true
+	if err := conflict.Init(); err == nil || !strings.Contains(err.Error(), "already exists") ||
+		!strings.Contains(err.Error(), "NotExist not found") {
+		t.Errorf("expect include warnings, got %v", err)
+	}
+}
+
+func TestIncludePlanNestedProps(t *testing.T) {
+	stage := running.NewPlan(running.NestedProps{
+		"":      {"value": "global"},
+		"S1":    {"key": "k1"},
+		"L1":    {"max_loop": 1, "value": "cluster"},
+		"L1.S2": {"key": "k2"},
+	}, nil,
+		running.AddNodes("SetState", "S1", "S2"),
+		running.AddNodes("Loop", "L1"),
+		running.MergeNodes("L1", "S2"),
+		running.LinkNodes("S1", "L1"))
+
+	if err := running.RegisterPlan("TestIncludePlanNestedStage", stage); err != nil {
+		t.Errorf("register plan failed, err=%s", err.Error())
+		return
+	}
+
+	plan := running.NewPlan(running.StandardProps{}, nil,
+		running.IncludePlan("TestIncludePlanNestedStage", "N_"))
+
+	if err := running.RegisterPlan("TestIncludePlanNested", plan); err != nil {
+		t.Errorf("register plan failed, err=%s", err.Error())
+		return
+	}
+
+	output := <-running.ExecPlan("TestIncludePlanNested", context.Background())
+	if output.Err != nil {
+		t.Errorf("exec plan failed, err=%s", output.Err.Error())
+		return
+	}
+
+	for key, expect := range map[string]string{"k1": "global", "k2": "cluster"} {
+		if v, _ := output.State.Query(key); v != expect {
+			t.Errorf("expect %s=%s, got %v", key, expect, v)
+		}
+	}
+}
+
+func TestIncludePlanCycle(t *testing.T) {
+	e := running.NewDefaultEngine()
+	e.RegisterNodeBuilder("Nothing", func(name string, props running.Props) (running.Node, error) {
+		node := new(NothingNode)
+		node.SetName(name)
+		return node, nil
+	})
+
+	if err := e.RegisterPlan("A", running.NewPlan(nil, nil, running.AddNodes("Nothing", "A1"), running.LinkNodes("A1"))); err != nil {
+		t.Errorf("register plan failed, err=%s", err.Error())
+		return
+	}
+
+	if err := e.RegisterPlan("B", running.NewPlan(nil, nil, e.IncludePlan("A", "A_"))); err != nil {
+		t.Errorf("register plan failed, err=%s", err.Error())
+		return
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+
+		err := e.UpdatePlan("A", func(plan *running.Plan) {
+			plan.Strict = true
+			plan.Options = append(plan.Options, e.IncludePlan("A", "Self_"))
+		})
+		if err == nil || !strings.Contains(err.Error(), "can not include itself") {
+			t.Errorf("expect self include error, got %v", err)
+		}
+
+		err = e.UpdatePlan("A", func(plan *running.Plan) {
+			plan.Options = append(plan.Options[:2], e.IncludePlan("B", "B_"))
+		})
+		if err == nil || !strings.Contains(err.Error(), "cyclic include") {
+			t.Errorf("expect cyclic include error, got %v", err)
+		}
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Errorf("update plan including itself is blocked")
+	}
+}
@@ -0,0 +1,114 @@
+package test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/symphony09/running"
+)
+
+func TestPlanInheritance(t *testing.T) {
+	base := running.NewPlan(running.StandardProps{
+		"S1.key":   "k1",
+		"S1.value": "base",
+		"S2.key":   "k2",
+		"S2.value": "base",
+		"B1.tag":   "base",
+	}, nil,
+		running.AddNodes("SetState", "S1", "S2"),
+		running.AddNodes("BaseTest", "B1"),
+		running.SLinkNodes("S1", "B1", "S2"))
+
+	if err := running.RegisterPlan("TestPlanInheritanceBase", base); err != nil {
+		t.Errorf("register plan failed, err=%s", err.Error())
+		return
+	}
+
+	derived := running.NewPlan(running.StandardProps{
+		"S2.value": "derived",
+		"S3.key":   "k3",
+		"S3.value": "derived",
+	}, nil,
+		running.RemoveNodes("B1"),
+		running.WrapNodes("Debug", "S1"),
+		running.AddNodes("SetState", "S3"),
+		running.SLinkNodes("S1", "S2", "S3"))
+	derived.Base = "TestPlanInheritanceBase"
+	derived.Strict = true
+
+	if err := running.RegisterPlan("TestPlanInheritance", derived); err != nil {
+		t.Errorf("register plan failed, err=%s", err.Error())
+		return
+	}
+
+	jsonPlan := []byte(`{
+		"Base": "TestPlanInheritanceBase",
+		"Props": {"S1.value": "json"},
+		"Graph": [{"Node": {"Name": "S2"}, "NextNodes": ["S4"]}, {"Node": {"Name": "S4", "Type": "SetState"}}],
+		"Overrides": {"NodeTypes": {"B1": "SetState"}, "Wrappers": {"S2": ["Debug"]}}
+	}`)
+
+	if err := running.LoadPlanFromJson("TestPlanInheritanceJson", jsonPlan, nil); err != nil {
+		t.Errorf("load plan failed, err=%s", err.Error())
+		return
+	}
+
+	info := running.Inspect(running.Global).DescribePlan("TestPlanInheritanceJson")
+	for _, vertex := range info.Vertexes {
+		if vertex.VertexName == "B1" && vertex.NodeInfo.NodeType != "SetState" {
+			t.Errorf("expect B1 type replaced, got %s", vertex.NodeInfo.NodeType)
+		}
+
+		if vertex.VertexName == "S2" && len(vertex.NodeInfo.Wrappers) != 1 {
+			t.Errorf("expect S2 wrapped, got %v", vertex.NodeInfo.Wrappers)
+		}
+	}
+
+	if len(info.Vertexes) != 4 {
+		t.Errorf("expect 4 vertexes, got %d", len(info.Vertexes))
+	}
+
+	check := func(name string, expect map[string]interface{}) {
+		output := <-running.ExecPlan(name, context.Background())
+		if output.Err != nil {
+			t.Errorf("exec plan %s failed, err=%s", name, output.Err.Error())
+			return
+		}
+
+		for key, value := range expect {
+			if v, _ := output.State.Query(key); v != value {
+				t.Errorf("plan %s expect %s=%v, got %v", name, key, value, v)
+			}
+		}
+	}
+
+	check("TestPlanInheritance", map[string]interface{}{"k1": "base", "k2": "derived", "k3": "derived"})
+
+	// update of base plan is resolved into dependants
+	err := running.UpdatePlan("TestPlanInheritanceBase", func(plan *running.Plan) {
+		plan.Props = running.StandardProps{
+			"S1.key":   "k1",
+			"S1.value": "updated",
+			"S2.key":   "k2",
+			"S2.value": "updated",
+		}
+	})
+	if err != nil {
+		t.Errorf("update plan failed, err=%s", err.Error())
+		return
+	}
+
+	check("TestPlanInheritance", map[string]interface{}{"k1": "updated", "k2": "derived", "k3": "derived"})
+	check("TestPlanInheritanceJson", map[string]interface{}{"k1": "json", "k2": "updated"})
+
+	orphan := running.NewPlan(nil, nil)
+	orphan.Base = "NotExist"
+	orphan.Strict = true
+	if err = running.RegisterPlan("TestPlanInheritanceOrphan", orphan); err == nil {
+		t.Errorf("expect error when base plan not found")
+	}
+
+	if err = running.ValidateJsonPlan([]byte(`{"Overrides": {"RemoveNodes": ["A"]}}`)); err == nil {
+		t.Errorf("expect error when overrides without base")
+	}
+}
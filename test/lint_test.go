@@ -0,0 +1,85 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/symphony09/running"
+)
+
+func TestLintPlan(t *testing.T) {
+	e := running.NewDefaultEngine()
+	e.RegisterNodeBuilder("Nothing", func(name string, props running.Props) (running.Node, error) {
+		node := new(NothingNode)
+		node.SetName(name)
+		return node, nil
+	})
+	e.RegisterNodeBuilder("Plain", func(name string, props running.Props) (running.Node, error) {
+		return new(PlainNode), nil
+	})
+
+	plan := running.NewPlan(nil, nil,
+		running.AddNodes("Nothing", "N1", "N2", "N3"),
+		running.AddNodes("Plain", "P1"),
+		running.AddNodes("Missing", "M1"),
+		running.AddVirtualNodes("V1"),
+		running.MergeNodes("P1", "N2"),
+		running.MergeNodes("N1", "M1"),
+		running.WrapNodes("Plain", "N1"),
+		running.WrapNodes("Unknown", "N1"),
+		running.MarkNodes("sub", "N2"),
+		running.LinkNodes("N1", "P1"),
+		running.LinkNodes("V1"))
+
+	if err := e.RegisterPlan("TestLintPlan", plan); err != nil {
+		t.Errorf("register plan failed, err=%s", err.Error())
+		return
+	}
+
+	issues, err := e.LintPlan("TestLintPlan")
+	if err != nil {
+		t.Errorf("lint plan failed, err=%s", err.Error())
+		return
+	}
+
+	expect := []string{
+		"[missing-builder] M1: no builder found for type Missing",
+		"[not-wrapper] N1: wrapper Plain does not implement Wrapper, it will be skipped",
+		"[missing-wrapper] N1: no builder found for wrapper Unknown, it will be skipped",
+		"[unused-label] N2: labels [sub] only take effect on vertexes",
+		"[unlinked-node] N3: node is never linked as vertex or merged as sub-node",
+		"[not-cluster] P1: type Plain does not implement Cluster, sub-nodes will be ignored",
+		"[isolated-virtual] V1: virtual node has no edges",
+	}
+
+	if len(issues) != len(expect) {
+		t.Errorf("expect %d issues, but got %d: %v", len(expect), len(issues), issues)
+		return
+	}
+
+	for i, issue := range issues {
+		if issue.Error() != expect[i] {
+			t.Errorf("expect issue %s, but got %s", expect[i], issue.Error())
+		}
+	}
+
+	e.StrictRegistration = true
+
+	var lintErr running.LintIssues
+	if err = e.RegisterPlan("TestLintPlanStrict", plan); !errors.As(err, &lintErr) {
+		t.Errorf("expect lint issues in strict registration, but got %v", err)
+	}
+
+	if _, err = e.LintPlan("TestLintPlanStrict"); !errors.Is(err, running.ErrPlanNotFound) {
+		t.Errorf("expect plan not found, but got %v", err)
+	}
+}
+
+type PlainNode struct{}
+
+func (node *PlainNode) Name() string { return "plain" }
+
+func (node *PlainNode) Run(ctx context.Context) {}
+
+func (node *PlainNode) Reset() {}
@@ -0,0 +1,83 @@
+package test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/symphony09/running"
+	"github.com/symphony09/running/utils"
+)
+
+func TestMutationOptions(t *testing.T) {
+	props := running.StandardProps{
+		"S1.key":      "k1",
+		"S1.value":    "v1",
+		"S2.key":      "k2",
+		"S2.value":    "v2",
+		"L1.S3.key":   "k3",
+		"L1.S3.value": "v3",
+		"L1.max_loop": 1,
+	}
+
+	plan := running.NewPlan(props, nil,
+		running.AddNodes("SetState", "S1", "S2", "S3"),
+		running.AddNodes("BaseTest", "B1"),
+		running.AddNodes("Loop", "L1"),
+		running.MergeNodes("L1", "S3"),
+		running.WrapNodes("Debug", "S1", "B1"),
+		running.MarkNodes("set", "S1", "S2"),
+		running.SLinkNodes("S1", "B1", "S2"),
+		running.LinkNodes("S1", "L1"))
+
+	if err := running.RegisterPlan("TestMutationOptions", plan); err != nil {
+		t.Errorf("register plan failed, err=%s", err.Error())
+		return
+	}
+
+	err := running.UpdatePlan("TestMutationOptions", func(plan *running.Plan) {
+		plan.Strict = true
+		plan.Options = append(plan.Options,
+			running.RemoveNodes("B1"),
+			running.LinkNodes("S1", "S2"),
+			running.UnlinkNodes("S1", "L1"),
+			running.RenameNode("S3", "S4"),
+			running.RenameNode("S2", "S5"),
+			running.ReplaceNodeType("Nothing", "S1"),
+			running.UnwrapNodes("Debug", "S1"),
+			running.UnmarkNodes("set", "S1"))
+	})
+	if err != nil {
+		t.Errorf("update plan failed, err=%s", err.Error())
+		return
+	}
+
+	running.ClearPool("TestMutationOptions")
+
+	info := running.Inspect(running.Global).DescribePlan("TestMutationOptions")
+	if len(info.Edges) != 1 || info.Edges[0] != (running.Edge{From: "S1", To: "S5"}) {
+		t.Errorf("expect edges [{S1 S5}], but got %v", info.Edges)
+	}
+
+	output := <-running.ExecPlan("TestMutationOptions", context.Background())
+	if output.Err != nil {
+		t.Errorf("exec plan failed, err=%s", output.Err.Error())
+		return
+	}
+
+	helper := utils.ProxyState(output.State)
+	if helper.GetString("k1") != "" || helper.GetString("k2") != "v2" || helper.GetString("k3") != "v3" {
+		t.Errorf("expect only renamed nodes set state, got k1=%s k2=%s k3=%s",
+			helper.GetString("k1"), helper.GetString("k2"), helper.GetString("k3"))
+	}
+
+	if logs := utils.GetRunSummary(output.State).Logs; len(logs["B1"]) != 0 || len(logs["S5"]) != 1 || len(logs["L1.S4"]) != 1 {
+		t.Errorf("wrong run logs, got %v", logs)
+	}
+
+	err = running.UpdatePlan("TestMutationOptions", func(plan *running.Plan) {
+		plan.Options = append(plan.Options, running.UnlinkNodes("S1", "S5"), running.RenameNode("S1", "S5"))
+	})
+	if err == nil {
+		t.Error("expect warnings of strict plan")
+	}
+}
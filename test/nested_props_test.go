@@ -0,0 +1,86 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/symphony09/running"
+)
+
+func TestNestedProps(t *testing.T) {
+	props := running.NestedProps{
+		"":   {"value": "global"},
+		"L1": {"max_loop": 1, "key": "k1"},
+		"S2": {"key": "k2"},
+		"S3": {"key": "k3", "value": "own"},
+	}
+
+	if v, _ := props.SubGet("L1.S1", "key"); v != "k1" {
+		t.Errorf("expect sub-node inherit cluster props, got %v", v)
+	}
+
+	if v, _ := props.SubGet("L1.S1", "value"); v != "global" {
+		t.Errorf("expect sub-node inherit global props, got %v", v)
+	}
+
+	plan := running.NewPlan(props, nil,
+		running.AddNodes("Loop", "L1"),
+		running.AddNodes("SetState", "S1", "S2", "S3"),
+		running.MergeNodes("L1", "S1"),
+		running.SLinkNodes("L1", "S2", "S3"))
+
+	data, err := json.Marshal(plan)
+	if err != nil {
+		t.Errorf("marshal plan failed, err=%s", err.Error())
+		return
+	}
+
+	if err = running.LoadPlanFromJson("TestNestedProps", data, nil); err != nil {
+		t.Errorf("load plan failed, err=%s", err.Error())
+		return
+	}
+
+	output := <-running.ExecPlan("TestNestedProps", context.Background())
+	if output.Err != nil {
+		t.Errorf("exec plan failed, err=%s", output.Err.Error())
+		return
+	}
+
+	for key, expect := range map[string]string{"k1": "global", "k2": "global", "k3": "own"} {
+		if v, _ := output.State.Query(key); v != expect {
+			t.Errorf("expect %s=%s, got %v", key, expect, v)
+		}
+	}
+
+	info := running.Inspect(running.Global).DescribePlan("TestNestedProps")
+	if info.GlobalProps["value"] != "global" {
+		t.Errorf("expect global props, got %v", info.GlobalProps)
+	}
+
+	for _, vertex := range info.Vertexes {
+		if vertex.VertexName == "L1" && vertex.NodeInfo.Props["key"] != "k1" {
+			t.Errorf("expect L1 props, got %v", vertex.NodeInfo.Props)
+		}
+	}
+
+	err = running.UpdatePlan("TestNestedProps", func(plan *running.Plan) {
+		plan.Options = append(plan.Options, running.RenameNode("S3", "S4"))
+	})
+	if err != nil {
+		t.Errorf("update plan failed, err=%s", err.Error())
+		return
+	}
+
+	data, _ = running.ExportPlan("TestNestedProps")
+	var exported running.JsonPlan
+	_ = json.Unmarshal(data, &exported)
+	if exported.NestedProps["S4"]["value"] != "own" || exported.NestedProps["S3"] != nil {
+		t.Errorf("expect props of renamed node moved, got %v", exported.NestedProps)
+	}
+
+	err = running.ValidateJsonPlan([]byte(`{"Props": {"a": 1}, "NestedProps": {"": {"a": 1}, "A": 1}}`))
+	if errs, ok := err.(running.PlanValidationErrors); !ok || len(errs) != 2 {
+		t.Errorf("expect 2 validation errors, got %v", err)
+	}
+}
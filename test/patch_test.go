@@ -0,0 +1,214 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+
+	"github.com/symphony09/running"
+)
+
+func TestApplyJsonPatch(t *testing.T) {
+	doc := []byte(`{"a":{"b":[1,2]},"c~d":"x","e/f":true}`)
+	patch := []byte(`[
+		{"op":"test","path":"/a/b/0","value":1},
+		{"op":"add","path":"/a/b/-","value":3},
+		{"op":"add","path":"/a/b/0","value":0},
+		{"op":"remove","path":"/a/b/1"},
+		{"op":"replace","path":"/c~0d","value":"y"},
+		{"op":"move","from":"/e~1f","path":"/g"},
+		{"op":"copy","from":"/a/b","path":"/h"}
+	]`)
+
+	data, err := running.ApplyJsonPatch(doc, patch)
+	if err != nil {
+		t.Errorf("apply patch failed, err=%s", err.Error())
+		return
+	}
+
+	expect := `{"a":{"b":[0,2,3]},"c~d":"y","g":true,"h":[0,2,3]}`
+	if string(data) != expect {
+		t.Errorf("expect %s, got %s", expect, string(data))
+	}
+
+	bad := [][]byte{
+		[]byte(`[{"op":"test","path":"/a/b/0","value":2}]`),
+		[]byte(`[{"op":"remove","path":"/x"}]`),
+		[]byte(`[{"op":"add","path":"/a/b/5","value":1}]`),
+		[]byte(`[{"op":"move","from":"/a","path":"/a/b/0"}]`),
+		[]byte(`[{"op":"unknown","path":"/a"}]`),
+	}
+
+	for _, patch := range bad {
+		if _, err = running.ApplyJsonPatch(doc, patch); err == nil {
+			t.Errorf("expect error for patch %s", string(patch))
+		}
+	}
+}
+
+func TestPatchPlan(t *testing.T) {
+	props := running.StandardProps{
+		"S1.key":   "k1",
+		"S1.value": "v1",
+	}
+
+	plan := running.NewPlan(props, nil,
+		running.AddNodes("SetState", "S1"),
+		running.AddNodes("BaseTest", "B1"),
+		running.LinkNodes("B1", "S1"))
+
+	if err := running.RegisterPlan("TestPatchPlan", plan); err != nil {
+		t.Errorf("register plan failed, err=%s", err.Error())
+		return
+	}
+
+	// vertexes are exported sorted by name, B1 is /Graph/0 and S1 is /Graph/1
+	err := running.PatchPlan("TestPatchPlan", []byte(`[
+		{"op":"replace","path":"/Props/S1.value","value":"v2"},
+		{"op":"add","path":"/Graph/-","value":{"Node":{"Name":"S2","Type":"SetState"},"NextNodes":[]}},
+		{"op":"add","path":"/Graph/1/NextNodes/-","value":"S2"},
+		{"op":"add","path":"/Props/S2.key","value":"k2"},
+		{"op":"add","path":"/Props/S2.value","value":"v2"}
+	]`))
+	if err != nil {
+		t.Errorf("patch plan failed, err=%s", err.Error())
+		return
+	}
+
+	output := <-running.ExecPlan("TestPatchPlan", context.Background())
+	if output.Err != nil {
+		t.Errorf("exec plan failed, err=%s", output.Err.Error())
+		return
+	}
+
+	for key, expect := range map[string]string{"k1": "v2", "k2": "v2"} {
+		if v, _ := output.State.Query(key); v != expect {
+			t.Errorf("expect %s=%s, got %v", key, expect, v)
+		}
+	}
+
+	// cycle is rejected and the plan stays unchanged
+	err = running.PatchPlan("TestPatchPlan", []byte(`[{"op":"add","path":"/Graph/2/NextNodes/-","value":"B1"}]`))
+	if err == nil {
+		t.Errorf("expect patch with cycle failed")
+	}
+
+	// missing builder is rejected
+	err = running.PatchPlan("TestPatchPlan", []byte(`[{"op":"replace","path":"/Graph/0/Node/Type","value":"NotExist"}]`))
+	var issues running.LintIssues
+	if !errors.As(err, &issues) || issues[0].Rule != running.LintRuleMissingBuilder {
+		t.Errorf("expect missing builder issue, got %v", err)
+	}
+
+	data, _ := running.ExportPlan("TestPatchPlan")
+	expect := `{"Props":{"S1.key":"k1","S1.value":"v2","S2.key":"k2","S2.value":"v2"},"Graph":[` +
+		`{"Node":{"Name":"B1","Type":"BaseTest","SubNodes":null,"Wrappers":null,"ReUse":false,"Virtual":false,"Labels":null},"NextNodes":["S1"]},` +
+		`{"Node":{"Name":"S1","Type":"SetState","SubNodes":null,"Wrappers":null,"ReUse":false,"Virtual":false,"Labels":null},"NextNodes":["S2"]},` +
+		`{"Node":{"Name":"S2","Type":"SetState","SubNodes":null,"Wrappers":null,"ReUse":false,"Virtual":false,"Labels":null},"NextNodes":[]}]}`
+	if string(data) != expect {
+		t.Errorf("expect plan %s, got %s", expect, string(data))
+	}
+
+	if err = running.PatchPlan("NotExist", []byte(`[]`)); !errors.Is(err, running.ErrPlanNotFound) {
+		t.Errorf("expect ErrPlanNotFound, got %v", err)
+	}
+}
+
+func TestPatchPlanSecret(t *testing.T) {
+	props := running.StandardProps{
+		"S1.key":   "k1",
+		"S1.value": running.Secret("s1"),
+		"S2.key":   "k2",
+	}
+
+	plan := running.NewPlan(props, nil,
+		running.AddNodes("SetState", "S1", "S2"),
+		running.MarkNodes("secret", "S2"),
+		running.PropsForLabel("secret", map[string]interface{}{"value": running.Secret("s2")}),
+		running.LinkNodes("S1", "S2"))
+
+	if err := running.RegisterPlan("TestPatchPlanSecret", plan); err != nil {
+		t.Errorf("register plan failed, err=%s", err.Error())
+		return
+	}
+
+	err := running.PatchPlan("TestPatchPlanSecret", []byte(`[{"op":"replace","path":"/Props/S2.key","value":"k3"}]`))
+	if err != nil {
+		t.Errorf("patch plan failed, err=%s", err.Error())
+		return
+	}
+
+	output := <-running.ExecPlan("TestPatchPlanSecret", context.Background())
+	if output.Err != nil {
+		t.Errorf("exec plan failed, err=%s", output.Err.Error())
+		return
+	}
+
+	if v, _ := output.State.Query("k1"); v != "s1" {
+		t.Errorf("expect k1=s1, got %v", v)
+	}
+
+	// secrets of props groups are unwrapped like plan props
+	if v, _ := output.State.Query("k3"); v != "s2" {
+		t.Errorf("expect k3=s2, got %#v", v)
+	}
+
+	// secret replaced by patch is a plain value
+	err = running.PatchPlan("TestPatchPlanSecret", []byte(`[{"op":"replace","path":"/Props/S1.value","value":"plain"}]`))
+	if err != nil {
+		t.Errorf("patch plan failed, err=%s", err.Error())
+		return
+	}
+
+	output = <-running.ExecPlan("TestPatchPlanSecret", context.Background())
+	if v, _ := output.State.Query("k1"); v != "plain" {
+		t.Errorf("expect k1=plain, got %v", v)
+	}
+}
+
+func TestPatchPlanPreflight(t *testing.T) {
+	var builds int32
+
+	e := running.NewDefaultEngine()
+	e.PreflightBuild = true
+	e.RegisterNodeBuilder("SetState", func(name string, props running.Props) (running.Node, error) {
+		atomic.AddInt32(&builds, 1)
+		node := new(SetStateNode)
+		node.SetName(name)
+		key, _ := props.SubGet(name, "key")
+		node.key, _ = key.(string)
+		node.value, _ = props.SubGet(name, "value")
+		return node, nil
+	})
+
+	plan := running.NewPlan(running.StandardProps{"S1.key": "k1", "S1.value": "v1"}, nil,
+		running.AddNodes("SetState", "S1"),
+		running.LinkNodes("S1"))
+
+	if err := e.RegisterPlan("TestPatchPlanPreflight", plan); err != nil {
+		t.Errorf("register plan failed, err=%s", err.Error())
+		return
+	}
+
+	err := e.PatchPlan("TestPatchPlanPreflight", []byte(`[{"op":"replace","path":"/Props/S1.value","value":"v2"}]`))
+	if err != nil {
+		t.Errorf("patch plan failed, err=%s", err.Error())
+		return
+	}
+
+	output := <-e.ExecPlan("TestPatchPlanPreflight", context.Background())
+	if output.Err != nil {
+		t.Errorf("exec plan failed, err=%s", output.Err.Error())
+		return
+	}
+
+	if v, _ := output.State.Query("k1"); v != "v2" {
+		t.Errorf("expect k1=v2, got %v", v)
+	}
+
+	// patched plan is built by preflight, pooled workers may be dropped by sync.Pool, so it is built at least twice
+	if n := atomic.LoadInt32(&builds); n < 2 {
+		t.Errorf("expect node built by preflight of the patched plan, got %d builds", n)
+	}
+}
@@ -0,0 +1,118 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/symphony09/running"
+)
+
+func TestFilePlanStore(t *testing.T) {
+	e := running.NewDefaultEngine()
+	e.RegisterNodeBuilder("Nothing", func(name string, props running.Props) (running.Node, error) {
+		node := new(NothingNode)
+		node.SetName(name)
+		return node, nil
+	})
+
+	dir, err := os.MkdirTemp("", "running-plans")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	defer os.RemoveAll(dir)
+
+	store := running.NewFilePlanStore(dir, 10*time.Millisecond)
+
+	data, err := json.Marshal(running.NewPlan(nil, nil,
+		running.AddNodes("Nothing", "N1"),
+		running.LinkNodes("N1")))
+	if err != nil {
+		t.Errorf("marshal plan failed, err=%s", err.Error())
+		return
+	}
+
+	if err = store.Put("P1", data); err != nil {
+		t.Errorf("put plan failed, err=%s", err.Error())
+		return
+	}
+
+	// names escaping the directory are rejected
+	for _, name := range []string{"../P1", "..", "sub/P1", ""} {
+		if err = store.Put(name, data); err == nil {
+			t.Errorf("expect put plan %q failed", name)
+		}
+
+		if _, err = store.Get(name); err == nil {
+			t.Errorf("expect get plan %q failed", name)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	if err = e.WatchPlanStore(ctx, store); err != nil {
+		t.Errorf("watch plan store failed, err=%s", err.Error())
+		return
+	}
+
+	if out := <-e.ExecPlan("P1", context.Background()); out.Err != nil {
+		t.Errorf("exec plan failed, err=%s", out.Err.Error())
+		return
+	}
+
+	// broken plan should not replace the running one
+	if err = store.Put("P1", []byte("{broken")); err != nil {
+		t.Errorf("put plan failed, err=%s", err.Error())
+		return
+	}
+
+	if !waitFor(func() bool { return len(running.Inspect(e).GetPlanLoadErrors()) == 1 }) {
+		t.Error("expect load error reported")
+		return
+	}
+
+	if out := <-e.ExecPlan("P1", context.Background()); out.Err != nil {
+		t.Errorf("expect previous plan still running, but got err=%s", out.Err.Error())
+	}
+
+	data, _ = json.Marshal(running.NewPlan(nil, nil,
+		running.AddNodes("Nothing", "N1", "N2"),
+		running.SLinkNodes("N1", "N2")))
+	if err = store.Put("P1", data); err != nil {
+		t.Errorf("put plan failed, err=%s", err.Error())
+		return
+	}
+
+	if !waitFor(func() bool {
+		return len(running.Inspect(e).GetPlanLoadErrors()) == 0 &&
+			len(running.Inspect(e).DescribePlan("P1").Vertexes) == 2
+	}) {
+		t.Errorf("expect plan reloaded, got %v", running.Inspect(e).GetPlanLoadErrors())
+		return
+	}
+
+	if err = os.Remove(dir + "/P1.json"); err != nil {
+		t.Error(err)
+		return
+	}
+
+	if !waitFor(func() bool { return len(running.Inspect(e).GetPlansName()) == 0 }) {
+		t.Error("expect plan removed")
+	}
+}
+
+func waitFor(cond func() bool) bool {
+	for i := 0; i < 100; i++ {
+		if cond() {
+			return true
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	return false
+}
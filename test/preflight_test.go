@@ -0,0 +1,71 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/symphony09/running"
+	"github.com/symphony09/running/common"
+)
+
+var errBrokenBuilder = errors.New("broken builder")
+
+func TestPreflightBuild(t *testing.T) {
+	e := running.NewDefaultEngine()
+	e.PreflightBuild = true
+	e.RegisterNodeBuilder("Serial", common.NewSerialCluster)
+	e.RegisterNodeBuilder("Nothing", func(name string, props running.Props) (running.Node, error) {
+		node := new(NothingNode)
+		node.SetName(name)
+		return node, nil
+	})
+	e.RegisterNodeBuilder("Broken", func(name string, props running.Props) (running.Node, error) {
+		return nil, errBrokenBuilder
+	})
+
+	plan := running.NewPlan(nil, nil,
+		running.AddNodes("Serial", "S1", "S2", "S3"),
+		running.AddNodes("Nothing", "N1"),
+		running.AddNodes("Broken", "B1"),
+		running.MergeNodes("S1", "S2", "S3"),
+		running.MergeNodes("S2", "N1"),
+		running.MergeNodes("S3", "B1"),
+		running.LinkNodes("S1"))
+
+	err := e.RegisterPlan("TestPreflightBuild", plan)
+
+	var buildErr *running.BuildError
+	if !errors.As(err, &buildErr) {
+		t.Errorf("expect build error, but got %v", err)
+		return
+	}
+
+	if buildErr.NodePath != "S1.S3.B1" || buildErr.NodeType != "Broken" || buildErr.Builder != "Broken" {
+		t.Errorf("wrong build error, got %+v", buildErr)
+	}
+
+	if !errors.Is(err, errBrokenBuilder) || !errors.Is(err, running.ErrBuildWorkerFailed) {
+		t.Errorf("expect build error wraps cause and ErrBuildWorkerFailed, but got %v", err)
+	}
+
+	if plans := running.Inspect(e).GetPlansName(); len(plans) != 0 {
+		t.Errorf("expect plan rejected, but got %v", plans)
+	}
+
+	plan = running.NewPlan(nil, nil,
+		running.AddNodes("Nothing", "N1"),
+		running.WrapNodes("Broken", "N1"),
+		running.LinkNodes("N1"))
+
+	e.PreflightBuild = false
+	if err = e.RegisterPlan("TestPreflightBuild", plan); err != nil {
+		t.Errorf("register plan failed, err=%s", err.Error())
+		return
+	}
+
+	out := <-e.ExecPlan("TestPreflightBuild", context.Background())
+	if !errors.As(out.Err, &buildErr) || buildErr.Builder != "Broken" || buildErr.NodeType != "Nothing" {
+		t.Errorf("expect wrapper build error, but got %v", out.Err)
+	}
+}
@@ -0,0 +1,142 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/symphony09/running"
+	"github.com/symphony09/running/utils"
+)
+
+type ServerConfig struct {
+	Host string `json:"host"`
+
+	Port uint16
+
+	Timeout time.Duration `running:"prop:timeout;default:3s"`
+}
+
+type DecodeConfig struct {
+	Retries int `running:"prop:retries;required"`
+
+	Ratio float32 `running:"prop:ratio;default:0.5"`
+
+	Interval time.Duration `running:"prop:interval"`
+
+	Tags []string `running:"prop:tags;default:[\"a\",\"b\"]"`
+
+	Weights map[string]int `running:"prop:weights"`
+
+	Servers []ServerConfig `running:"prop:servers"`
+
+	Backup *ServerConfig `running:"prop:backup"`
+
+	Untagged string
+}
+
+func TestDecodeProps(t *testing.T) {
+	props := running.StandardProps{
+		"N.retries":  float64(3),
+		"N.interval": "2s",
+		"N.weights":  map[string]interface{}{"x": float64(1), "y": 2},
+		"N.servers": []interface{}{
+			map[string]interface{}{"host": "h1", "port": float64(80)},
+			map[string]interface{}{"host": "h2", "PORT": 8080, "timeout": "1s"},
+		},
+		"N.backup":   map[string]interface{}{"host": "h3"},
+		"N.Untagged": "x",
+	}
+
+	var cfg DecodeConfig
+	if err := utils.DecodeProps(props, "N", &cfg); err != nil {
+		t.Errorf("decode props failed, err=%s", err.Error())
+		return
+	}
+
+	if cfg.Retries != 3 || cfg.Ratio != 0.5 || cfg.Interval != 2*time.Second || cfg.Untagged != "" {
+		t.Errorf("unexpected scalar fields, got %+v", cfg)
+	}
+
+	if len(cfg.Tags) != 2 || cfg.Tags[0] != "a" || cfg.Tags[1] != "b" {
+		t.Errorf("expect default tags [a b], got %v", cfg.Tags)
+	}
+
+	if cfg.Weights["x"] != 1 || cfg.Weights["y"] != 2 {
+		t.Errorf("unexpected weights, got %v", cfg.Weights)
+	}
+
+	expectServers := []ServerConfig{{"h1", 80, 3 * time.Second}, {"h2", 8080, time.Second}}
+	if len(cfg.Servers) != 2 || cfg.Servers[0] != expectServers[0] || cfg.Servers[1] != expectServers[1] {
+		t.Errorf("expect servers %v, got %v", expectServers, cfg.Servers)
+	}
+
+	if cfg.Backup == nil || *cfg.Backup != (ServerConfig{Host: "h3", Timeout: 3 * time.Second}) {
+		t.Errorf("unexpected backup, got %v", cfg.Backup)
+	}
+
+	props = running.StandardProps{
+		"N.ratio":   "high",
+		"N.servers": []interface{}{map[string]interface{}{"port": 1.5}, map[string]interface{}{"port": -1}},
+	}
+
+	err := utils.DecodeProps(props, "N", &cfg)
+
+	var propsErrs running.PropsErrors
+	if !errors.As(err, &propsErrs) || len(propsErrs) != 4 {
+		t.Errorf("expect 4 props errors, but got %v", err)
+		return
+	}
+
+	for i, prop := range []string{"retries", "ratio", "servers[0].Port", "servers[1].Port"} {
+		if propsErrs[i].Prop != prop {
+			t.Errorf("expect error of %s, got %v", prop, propsErrs[i])
+		}
+	}
+
+	if err = utils.DecodeProps(props, "N", cfg); err == nil {
+		t.Errorf("expect error when decode into non pointer")
+	}
+}
+
+type RetryNode struct {
+	running.Base
+
+	Retries int `running:"prop:retries"`
+
+	Interval time.Duration `running:"prop:interval"`
+}
+
+func (node *RetryNode) Run(ctx context.Context) {
+	node.State.Update("retries", node.Retries)
+	node.State.Update("interval", node.Interval)
+}
+
+func TestRegisterNodesDecodeProps(t *testing.T) {
+	e := running.NewDefaultEngine()
+	if err := utils.RegisterNodes(e, &RetryNode{}); err != nil {
+		t.Error(err)
+		return
+	}
+
+	jsonData := `{"Props":{"R.retries":3,"R.interval":"1m"},"Graph":[{"Node":{"Name":"R","Type":"RetryNode"}}]}`
+	if err := e.LoadPlanFromJson("TestRegisterNodesDecodeProps", []byte(jsonData), nil); err != nil {
+		t.Errorf("load plan failed, err=%s", err.Error())
+		return
+	}
+
+	out := <-e.ExecPlan("TestRegisterNodesDecodeProps", context.Background())
+	if out.Err != nil {
+		t.Errorf("exec plan failed, err=%s", out.Err.Error())
+		return
+	}
+
+	if retries, _ := out.State.Query("retries"); retries != 3 {
+		t.Errorf("expect retries 3, got %v", retries)
+	}
+
+	if interval, _ := out.State.Query("interval"); interval != time.Minute {
+		t.Errorf("expect interval 1m, got %v", interval)
+	}
+}
@@ -0,0 +1,120 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/symphony09/running"
+)
+
+func TestPropsGroups(t *testing.T) {
+	props := running.StandardProps{
+		"S1.key":      "k1",
+		"S2.key":      "k2",
+		"S3.key":      "k3",
+		"S3.value":    "own",
+		"L1.S4.key":   "k4",
+		"L1.max_loop": 1,
+	}
+
+	plan := running.NewPlan(props, nil,
+		running.AddNodes("SetState", "S1", "S2", "S3", "S4"),
+		running.AddNodes("Loop", "L1"),
+		running.MergeNodes("L1", "S4"),
+		running.MarkNodes("special", "S1"),
+		running.SLinkNodes("S1", "S2", "S3", "L1"),
+		running.PropsForType("SetState", map[string]interface{}{"value": "type"}),
+		running.PropsForLabel("special", map[string]interface{}{"value": "label"}))
+
+	data, err := json.Marshal(plan)
+	if err != nil {
+		t.Errorf("marshal plan failed, err=%s", err.Error())
+		return
+	}
+
+	var jsonPlan running.JsonPlan
+	_ = json.Unmarshal(data, &jsonPlan)
+	if len(jsonPlan.PropsGroups) != 2 || jsonPlan.PropsGroups[0].Type != "SetState" {
+		t.Errorf("expect props groups exported, got %v", jsonPlan.PropsGroups)
+	}
+
+	if err = running.LoadPlanFromJson("TestPropsGroups", data, nil); err != nil {
+		t.Errorf("load plan failed, err=%s", err.Error())
+		return
+	}
+
+	output := <-running.ExecPlan("TestPropsGroups", context.Background())
+	if output.Err != nil {
+		t.Errorf("exec plan failed, err=%s", output.Err.Error())
+		return
+	}
+
+	for key, expect := range map[string]string{"k1": "label", "k2": "type", "k3": "own", "k4": "type"} {
+		if v, _ := output.State.Query(key); v != expect {
+			t.Errorf("expect %s=%s, got %v", key, expect, v)
+		}
+	}
+
+	err = running.ValidateJsonPlan([]byte(`{"PropsGroups": [{"Label": "a", "Type": "b", "Props": {}}, {"Props": 1}]}`))
+	if errs, ok := err.(running.PlanValidationErrors); !ok || len(errs) != 3 {
+		t.Errorf("expect 3 validation errors, got %v", err)
+	}
+}
+
+func TestPropsGroupsNested(t *testing.T) {
+	props := running.NestedProps{
+		"":      {"value": "global"},
+		"S1":    {"key": "k1"},
+		"S2":    {"key": "k2", "value": "own"},
+		"L1":    {"max_loop": 1, "value": "cluster"},
+		"L1.S3": {"key": "k3"},
+		"L1.S4": {"key": "k4"},
+	}
+
+	plan := running.NewPlan(props, nil,
+		running.AddNodes("SetState", "S1", "S2", "S3", "S4"),
+		running.AddNodes("Loop", "L1"),
+		running.MergeNodes("L1", "S3", "S4"),
+		running.MarkNodes("grouped", "S1", "S2", "S3"),
+		running.SLinkNodes("S1", "S2", "L1"),
+		running.PropsForLabel("grouped", map[string]interface{}{"value": "label"}))
+
+	if err := running.RegisterPlan("TestPropsGroupsNested", plan); err != nil {
+		t.Errorf("register plan failed, err=%s", err.Error())
+		return
+	}
+
+	output := <-running.ExecPlan("TestPropsGroupsNested", context.Background())
+	if output.Err != nil {
+		t.Errorf("exec plan failed, err=%s", output.Err.Error())
+		return
+	}
+
+	// props set at node path take precedence, then props groups, then inherited props
+	for key, expect := range map[string]string{"k1": "label", "k2": "own", "k3": "label", "k4": "cluster"} {
+		if v, _ := output.State.Query(key); v != expect {
+			t.Errorf("expect %s=%s, got %v", key, expect, v)
+		}
+	}
+
+	included := running.NewPlan(running.StandardProps{}, nil,
+		running.IncludePlan("TestPropsGroupsNested", "I_"))
+
+	if err := running.RegisterPlan("TestPropsGroupsNestedInclude", included); err != nil {
+		t.Errorf("register plan failed, err=%s", err.Error())
+		return
+	}
+
+	output = <-running.ExecPlan("TestPropsGroupsNestedInclude", context.Background())
+	if output.Err != nil {
+		t.Errorf("exec plan failed, err=%s", output.Err.Error())
+		return
+	}
+
+	for key, expect := range map[string]string{"k1": "label", "k2": "own", "k3": "label", "k4": "cluster"} {
+		if v, _ := output.State.Query(key); v != expect {
+			t.Errorf("expect %s=%s in included plan, got %v", key, expect, v)
+		}
+	}
+}
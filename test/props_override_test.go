@@ -0,0 +1,86 @@
+package test
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+
+	"github.com/symphony09/running"
+	"github.com/symphony09/running/common"
+)
+
+func TestPropsOverride(t *testing.T) {
+	var builds int32
+
+	e := running.NewDefaultEngine()
+	e.RegisterNodeBuilder("Loop", common.NewLoopCluster)
+	e.RegisterNodeBuilder("SetState", func(name string, props running.Props) (running.Node, error) {
+		atomic.AddInt32(&builds, 1)
+
+		node := new(SetStateNode)
+		node.SetName(name)
+		key, _ := props.SubGet(name, "key")
+		node.key, _ = key.(string)
+		node.value, _ = props.SubGet(name, "value")
+		return node, nil
+	})
+
+	props := running.StandardProps{
+		"S1.key":      "k1",
+		"S1.value":    "v1",
+		"S2.key":      "k2",
+		"S2.value":    "v2",
+		"L1.max_loop": 1,
+		"L1.S3.key":   "k3",
+		"L1.S3.value": "v3",
+	}
+
+	plan := running.NewPlan(props, nil,
+		running.AddNodes("SetState", "S1", "S2", "S3"),
+		running.AddNodes("Loop", "L1"),
+		running.MergeNodes("L1", "S3"),
+		running.SLinkNodes("S1", "S2", "L1"))
+
+	if err := e.RegisterPlan("TestPropsOverride", plan); err != nil {
+		t.Errorf("register plan failed, err=%s", err.Error())
+		return
+	}
+
+	exec := func(override running.NestedProps, expect map[string]string) {
+		ctx := context.WithValue(context.Background(), running.CtxKey, running.CtxParams{PropsOverride: override})
+
+		output := <-e.ExecPlan("TestPropsOverride", ctx)
+		if output.Err != nil {
+			t.Errorf("exec plan failed, err=%s", output.Err.Error())
+			return
+		}
+
+		for key, value := range expect {
+			if v, _ := output.State.Query(key); v != value {
+				t.Errorf("expect %s=%s, got %v", key, value, v)
+			}
+		}
+	}
+
+	exec(nil, map[string]string{"k1": "v1", "k2": "v2", "k3": "v3"})
+
+	// keep a built worker in pool, so the next exec checks it out instead of building a new one
+	e.WarmupPool("TestPropsOverride", 2)
+	atomic.StoreInt32(&builds, 0)
+
+	exec(running.NestedProps{"S1": {"value": "o1"}, "L1.S3": {"value": "o3"}},
+		map[string]string{"k1": "o1", "k2": "v2", "k3": "o3"})
+
+	if n := atomic.LoadInt32(&builds); n != 2 {
+		t.Errorf("expect only S1 and L1.S3 rebuilt, but got %d builds", n)
+	}
+
+	// pooled worker is not changed
+	exec(nil, map[string]string{"k1": "v1", "k2": "v2", "k3": "v3"})
+
+	ctx := context.WithValue(context.Background(), running.CtxKey,
+		running.CtxParams{PropsOverride: running.NestedProps{"NotExist": {"value": "x"}}})
+	if output := <-e.ExecPlan("TestPropsOverride", ctx); output.Err == nil {
+		t.Errorf("expect error when override props of unknown node")
+	}
+}
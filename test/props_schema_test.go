@@ -0,0 +1,83 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/symphony09/running"
+	"github.com/symphony09/running/utils"
+)
+
+type GreetNode struct {
+	running.Base
+
+	Username string `running:"prop:username;required;desc:who to greet"`
+
+	Times int `running:"prop:times;default:2"`
+
+	Timeout time.Duration `running:"prop:timeout;default:1s"`
+}
+
+func (node *GreetNode) Run(ctx context.Context) {
+	for i := 0; i < node.Times; i++ {
+		utils.AddLog(node.State, node.Name(), time.Now(), time.Now(), "Hello, "+node.Username, nil)
+	}
+}
+
+func TestPropsSchema(t *testing.T) {
+	e := running.NewDefaultEngine()
+	if err := utils.RegisterNodes(e, &GreetNode{}); err != nil {
+		t.Error(err)
+		return
+	}
+
+	schemas := running.Inspect(e).GetNodeBuildersProps()["GreetNode"]
+	expect := []running.PropSchema{
+		{Name: "username", Type: running.PropTypeString, Required: true, Description: "who to greet"},
+		{Name: "times", Type: running.PropTypeInt, Default: 2},
+		{Name: "timeout", Type: "time.Duration", Default: time.Second},
+	}
+
+	if len(schemas) != len(expect) {
+		t.Errorf("expect props schema %v, but got %v", expect, schemas)
+		return
+	}
+
+	for i := range expect {
+		if schemas[i] != expect[i] {
+			t.Errorf("expect props schema %v, but got %v", expect[i], schemas[i])
+		}
+	}
+
+	plan := running.NewPlan(running.StandardProps{"G2.times": "many"}, nil,
+		running.AddNodes("GreetNode", "G1", "G2"),
+		running.LinkNodes("G1", "G2"))
+
+	err := e.RegisterPlan("TestPropsSchema", plan)
+
+	var propsErrs running.PropsErrors
+	if !errors.As(err, &propsErrs) || len(propsErrs) != 3 {
+		t.Errorf("expect 3 props errors, but got %v", err)
+	}
+
+	plan = running.NewPlan(running.StandardProps{"G1.username": "Oliver"}, nil,
+		running.AddNodes("GreetNode", "G1"),
+		running.LinkNodes("G1"))
+
+	if err = e.RegisterPlan("TestPropsSchema", plan); err != nil {
+		t.Errorf("register plan failed, err=%s", err.Error())
+		return
+	}
+
+	out := <-e.ExecPlan("TestPropsSchema", context.Background())
+	if out.Err != nil {
+		t.Errorf("exec plan failed, err=%s", out.Err.Error())
+		return
+	}
+
+	if logs := utils.GetRunSummary(out.State).Logs["G1"]; len(logs) != 2 || logs[0].Msg != "Hello, Oliver" {
+		t.Errorf("expect default times applied, but got logs %v", logs)
+	}
+}
@@ -0,0 +1,200 @@
+package test
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+
+	"github.com/symphony09/running"
+)
+
+type ReconfigurableNode struct {
+	SetStateNode
+
+	reconfigures *int32
+}
+
+func (node *ReconfigurableNode) Reconfigure(props running.Props) error {
+	atomic.AddInt32(node.reconfigures, 1)
+
+	key, _ := props.SubGet(node.Name(), "key")
+	node.key, _ = key.(string)
+	node.value, _ = props.SubGet(node.Name(), "value")
+	return nil
+}
+
+func TestUpdatePlanProps(t *testing.T) {
+	var builds, reconfigures int32
+
+	e := running.NewDefaultEngine()
+	e.RegisterNodeBuilder("SetState", func(name string, props running.Props) (running.Node, error) {
+		atomic.AddInt32(&builds, 1)
+
+		node := new(SetStateNode)
+		node.SetName(name)
+		key, _ := props.SubGet(name, "key")
+		node.key, _ = key.(string)
+		node.value, _ = props.SubGet(name, "value")
+		return node, nil
+	})
+	e.RegisterNodeBuilder("Reconfigurable", func(name string, props running.Props) (running.Node, error) {
+		node := &ReconfigurableNode{reconfigures: &reconfigures}
+		node.SetName(name)
+		return node, node.Reconfigure(props)
+	})
+
+	plan := running.NewPlan(running.StandardProps{
+		"S1.key":   "k1",
+		"S1.value": "v1",
+		"R1.key":   "k2",
+		"R1.value": "v2",
+	}, nil,
+		running.AddNodes("SetState", "S1"),
+		running.AddNodes("Reconfigurable", "R1"),
+		running.LinkNodes("S1", "R1"))
+
+	if err := e.RegisterPlan("TestUpdatePlanProps", plan); err != nil {
+		t.Errorf("register plan failed, err=%s", err.Error())
+		return
+	}
+
+	exec := func(expect map[string]string) {
+		output := <-e.ExecPlan("TestUpdatePlanProps", context.Background())
+		if output.Err != nil {
+			t.Errorf("exec plan failed, err=%s", output.Err.Error())
+			return
+		}
+
+		for key, value := range expect {
+			if v, _ := output.State.Query(key); v != value {
+				t.Errorf("expect %s=%s, got %v", key, value, v)
+			}
+		}
+	}
+
+	exec(map[string]string{"k1": "v1", "k2": "v2"})
+
+	// keep a built worker in pool, so each exec checks out the same worker
+	e.WarmupPool("TestUpdatePlanProps", 2)
+	atomic.StoreInt32(&builds, 0)
+	atomic.StoreInt32(&reconfigures, 0)
+
+	err := e.UpdatePlanProps("TestUpdatePlanProps", running.StandardProps{
+		"S1.key":   "k1",
+		"S1.value": "n1",
+		"R1.key":   "k2",
+		"R1.value": "n2",
+	})
+	if err != nil {
+		t.Errorf("update plan props failed, err=%s", err.Error())
+		return
+	}
+
+	exec(map[string]string{"k1": "n1", "k2": "n2"})
+
+	if n := atomic.LoadInt32(&builds); n != 1 {
+		t.Errorf("expect S1 rebuilt once, but got %d builds", n)
+	}
+
+	if n := atomic.LoadInt32(&reconfigures); n != 1 {
+		t.Errorf("expect R1 reconfigured once, but got %d reconfigures", n)
+	}
+
+	// worker is reconfigured only once
+	exec(map[string]string{"k1": "n1", "k2": "n2"})
+
+	if n := atomic.LoadInt32(&builds) + atomic.LoadInt32(&reconfigures); n != 2 {
+		t.Errorf("expect no more builds or reconfigures, but got %d", n)
+	}
+
+	if err = e.UpdatePlanProps("NotExist", running.StandardProps{}); err == nil {
+		t.Errorf("expect error when update props of unknown plan")
+	}
+}
+
+type ReconfigurableWrapper struct {
+	running.BaseWrapper
+
+	reconfigures *int32
+}
+
+func (wrapper *ReconfigurableWrapper) Reconfigure(props running.Props) error {
+	atomic.AddInt32(wrapper.reconfigures, 1)
+	return nil
+}
+
+func TestUpdatePlanPropsWrapped(t *testing.T) {
+	var builds, wrapperBuilds, reconfigures int32
+
+	e := running.NewDefaultEngine()
+	e.RegisterNodeBuilder("SetState", func(name string, props running.Props) (running.Node, error) {
+		atomic.AddInt32(&builds, 1)
+
+		node := new(SetStateNode)
+		node.SetName(name)
+		key, _ := props.SubGet(name, "key")
+		node.key, _ = key.(string)
+		node.value, _ = props.SubGet(name, "value")
+		return node, nil
+	})
+	e.RegisterNodeBuilder("Reconfigurable", func(name string, props running.Props) (running.Node, error) {
+		node := &ReconfigurableNode{reconfigures: &reconfigures}
+		node.SetName(name)
+		return node, node.Reconfigure(props)
+	})
+	e.RegisterNodeBuilder("Wrapper", func(name string, props running.Props) (running.Node, error) {
+		atomic.AddInt32(&wrapperBuilds, 1)
+		return &ReconfigurableWrapper{reconfigures: &reconfigures}, nil
+	})
+
+	plan := running.NewPlan(running.StandardProps{
+		"S1.key":   "k1",
+		"S1.value": "v1",
+		"R1.key":   "k2",
+		"R1.value": "v2",
+	}, nil,
+		running.AddNodes("SetState", "S1"),
+		running.AddNodes("Reconfigurable", "R1"),
+		running.WrapAllNodes("Wrapper"),
+		running.LinkNodes("S1", "R1"))
+
+	if err := e.RegisterPlan("TestUpdatePlanPropsWrapped", plan); err != nil {
+		t.Errorf("register plan failed, err=%s", err.Error())
+		return
+	}
+
+	// pool is created on first exec
+	<-e.ExecPlan("TestUpdatePlanPropsWrapped", context.Background())
+	e.WarmupPool("TestUpdatePlanPropsWrapped", 2)
+	atomic.StoreInt32(&builds, 0)
+	atomic.StoreInt32(&wrapperBuilds, 0)
+	atomic.StoreInt32(&reconfigures, 0)
+
+	err := e.UpdatePlanProps("TestUpdatePlanPropsWrapped", running.StandardProps{
+		"S1.key":   "k1",
+		"S1.value": "n1",
+		"R1.key":   "k2",
+		"R1.value": "n2",
+	})
+	if err != nil {
+		t.Errorf("update plan props failed, err=%s", err.Error())
+		return
+	}
+
+	output := <-e.ExecPlan("TestUpdatePlanPropsWrapped", context.Background())
+	if output.Err != nil {
+		t.Errorf("exec plan failed, err=%s", output.Err.Error())
+		return
+	}
+
+	// S1 is not reconfigurable, so it is rebuilt with its wrapper, R1 and its wrapper are reconfigured
+	for key, value := range map[string]string{"k1": "n1", "k2": "n2"} {
+		if v, _ := output.State.Query(key); v != value {
+			t.Errorf("expect %s=%s, got %v", key, value, v)
+		}
+	}
+
+	if b, w, r := atomic.LoadInt32(&builds), atomic.LoadInt32(&wrapperBuilds), atomic.LoadInt32(&reconfigures); b != 1 || w != 1 || r != 2 {
+		t.Errorf("expect 1 build, 1 wrapper build and 2 reconfigures, got %d, %d and %d", b, w, r)
+	}
+}
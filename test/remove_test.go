@@ -0,0 +1,99 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/symphony09/running"
+)
+
+func TestRemovePlan(t *testing.T) {
+	e := running.NewDefaultEngine()
+	e.RegisterNodeBuilder("Nothing", func(name string, props running.Props) (running.Node, error) {
+		node := new(NothingNode)
+		node.SetName(name)
+		return node, nil
+	})
+
+	plan := running.NewPlan(nil, nil,
+		running.AddNodes("Nothing", "N1"),
+		running.LinkNodes("N1"))
+
+	if err := e.RegisterPlan("TestRemovePlan", plan); err != nil {
+		t.Errorf("register plan failed, err=%s", err.Error())
+		return
+	}
+
+	if out := <-e.ExecPlan("TestRemovePlan", context.Background()); out.Err != nil {
+		t.Errorf("exec plan failed, err=%s", out.Err.Error())
+		return
+	}
+
+	e.WarmupPool("TestRemovePlan", 4)
+
+	if err := e.RemovePlan("TestRemovePlan"); err != nil {
+		t.Errorf("remove plan failed, err=%s", err.Error())
+		return
+	}
+
+	if out := <-e.ExecPlan("TestRemovePlan", context.Background()); !errors.Is(out.Err, running.ErrPlanNotFound) {
+		t.Errorf("expect plan not found error, but got %v", out.Err)
+	}
+
+	if err := e.RemovePlan("TestRemovePlan"); !errors.Is(err, running.ErrPlanNotFound) {
+		t.Errorf("expect plan not found error, but got %v", err)
+	}
+
+	if plans := running.Inspect(e).GetPlansName(); len(plans) != 0 {
+		t.Errorf("expect no plans, but got %v", plans)
+	}
+}
+
+func TestUnregisterNodeBuilder(t *testing.T) {
+	e := running.NewDefaultEngine()
+	e.RegisterNodeBuilder("Nothing", func(name string, props running.Props) (running.Node, error) {
+		node := new(NothingNode)
+		node.SetName(name)
+		return node, nil
+	})
+	e.RegisterNodeBuilder("TimerWrapper", func(name string, props running.Props) (running.Node, error) {
+		return new(TimerWrapper), nil
+	})
+	e.SetNodeBuilderInfo("TimerWrapper", running.NodeBuilderInfo{Type: running.TypeOfWrapper})
+
+	plan := running.NewPlan(nil, nil,
+		running.AddNodes("Nothing", "N1"),
+		running.WrapNodes("TimerWrapper", "N1"),
+		running.LinkNodes("N1"))
+
+	if err := e.RegisterPlan("TestUnregisterNodeBuilder", plan); err != nil {
+		t.Errorf("register plan failed, err=%s", err.Error())
+		return
+	}
+
+	if err := e.UnregisterNodeBuilder("TimerWrapper", false); !errors.Is(err, running.ErrNodeBuilderInUse) {
+		t.Errorf("expect node builder in use error, but got %v", err)
+	}
+
+	if err := e.UnregisterNodeBuilder("TimerWrapper", true); err != nil {
+		t.Errorf("force unregister node builder failed, err=%s", err.Error())
+	}
+
+	if _, ok := running.Inspect(e).GetNodeBuildersInfo()["TimerWrapper"]; ok {
+		t.Error("expect TimerWrapper builder info removed")
+	}
+
+	if err := e.RemovePlan("TestUnregisterNodeBuilder"); err != nil {
+		t.Errorf("remove plan failed, err=%s", err.Error())
+		return
+	}
+
+	if err := e.UnregisterNodeBuilder("Nothing", false); err != nil {
+		t.Errorf("unregister node builder failed, err=%s", err.Error())
+	}
+
+	if builders := running.Inspect(e).GetNodeBuildersName(); len(builders) != 0 {
+		t.Errorf("expect no builders, but got %v", builders)
+	}
+}
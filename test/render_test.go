@@ -0,0 +1,63 @@
+package test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/symphony09/running"
+)
+
+func TestRenderPlan(t *testing.T) {
+	e := running.NewDefaultEngine()
+
+	plan := running.NewPlan(nil, nil,
+		running.AddNodes("Loop", "L1"),
+		running.AddNodes("BaseTest", "B1", "B2"),
+		running.AddVirtualNodes("V"),
+		running.MergeNodes("L1", "B1"),
+		running.WrapNodes("Debug", "B2"),
+		running.MarkNodes("fast", "B2"),
+		running.SLinkNodes("L1", "V", "B2"))
+
+	if err := e.RegisterPlan("TestRenderPlan", plan); err != nil {
+		t.Errorf("register plan failed, err=%s", err.Error())
+		return
+	}
+
+	info := running.Inspect(e).DescribePlan("TestRenderPlan")
+	trace := running.ExecTrace{
+		"L1.B1": {Status: running.TraceStatusSuccess, Duration: 10 * time.Millisecond},
+		"B2":    {Status: running.TraceStatusFailed},
+	}
+
+	dot := info.ToDOT(trace)
+	for _, expect := range []string{
+		"subgraph cluster_1 {",
+		`"L1.B1" [label="B1\nBaseTest\nsuccess 10ms", style=filled, fillcolor="#c8e6c9"];`,
+		`"B2" [label="B2\nBaseTest\nwrapped by Debug\n@fast\nfailed", style=filled, fillcolor="#ffcdd2"];`,
+		`"V" [label="V\n(virtual)", shape=ellipse, style=dashed];`,
+		`"L1" -> "V";`,
+		`"V" -> "B2";`,
+	} {
+		if !strings.Contains(dot, expect) {
+			t.Errorf("expect dot contains %s, got:\n%s", expect, dot)
+		}
+	}
+
+	mermaid := info.ToMermaid(trace)
+	for _, expect := range []string{
+		"flowchart LR",
+		`subgraph n1_group["L1"]`,
+		`n2["B1<br/>BaseTest<br/>success 10ms"]`,
+		`n3(("V<br/>(virtual)"))`,
+		"n1 --> n3",
+		"class n0 failed",
+		"class n2 success",
+		"class n3 virtual",
+	} {
+		if !strings.Contains(mermaid, expect) {
+			t.Errorf("expect mermaid contains %s, got:\n%s", expect, mermaid)
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/symphony09/running"
+)
+
+func TestPropsResolver(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "token")
+	if err := os.WriteFile(file, []byte("file-token\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("RUNNING_TEST_DB", "db-host")
+
+	e := running.NewDefaultEngine()
+	e.RegisterNodeBuilder("SetState", func(name string, props running.Props) (running.Node, error) {
+		node := new(SetStateNode)
+		node.SetName(name)
+		key, _ := props.SubGet(name, "key")
+		node.key, _ = key.(string)
+		node.value, _ = props.SubGet(name, "value")
+		return node, nil
+	})
+	e.PropsResolver = func(kind, ref string) (interface{}, error) {
+		if kind == "secret" {
+			if ref == "db_password" {
+				return running.Secret("p@ss"), nil
+			}
+			return nil, fmt.Errorf("secret %s not found", ref)
+		}
+
+		return running.DefaultPropsResolver(kind, ref)
+	}
+
+	props := running.StandardProps{
+		"S1.key":   "env",
+		"S1.value": "postgres://${env:RUNNING_TEST_DB}/app",
+		"S2.key":   "file",
+		"S2.value": "${file:" + file + "}",
+		"S3.key":   "secret",
+		"S3.value": "${secret:db_password}",
+		"S4.key":   "literal",
+		"S4.value": running.Secret("literal-secret"),
+		"S5.key":   "group",
+	}
+
+	plan := running.NewPlan(props, nil,
+		running.AddNodes("SetState", "S1", "S2", "S3", "S4", "S5"),
+		running.MarkNodes("db", "S5"),
+		running.PropsForLabel("db", map[string]interface{}{"value": "${env:RUNNING_TEST_DB}"}),
+		running.LinkNodes("S1", "S2", "S3", "S4", "S5"))
+
+	if err := e.RegisterPlan("TestPropsResolver", plan); err != nil {
+		t.Errorf("register plan failed, err=%s", err.Error())
+		return
+	}
+
+	output := <-e.ExecPlan("TestPropsResolver", context.Background())
+	if output.Err != nil {
+		t.Errorf("exec plan failed, err=%s", output.Err.Error())
+		return
+	}
+
+	expect := map[string]interface{}{
+		"env":     "postgres://db-host/app",
+		"file":    "file-token",
+		"secret":  "p@ss",
+		"literal": "literal-secret",
+		"group":   "db-host",
+	}
+	for key, value := range expect {
+		if v, _ := output.State.Query(key); v != value {
+			t.Errorf("expect %s=%v, got %v", key, value, v)
+		}
+	}
+
+	data, _ := e.ExportPlan("TestPropsResolver")
+	if strings.Contains(string(data), "literal-secret") || !strings.Contains(string(data), running.SecretMask) {
+		t.Errorf("secret leaked in exported plan %s", string(data))
+	}
+
+	info := running.Inspect(e).DescribePlan("TestPropsResolver")
+	for _, vertex := range info.Vertexes {
+		if vertex.VertexName == "S4" && vertex.NodeInfo.Props["value"] != running.SecretMask {
+			t.Errorf("secret leaked in plan info %v", vertex.NodeInfo.Props)
+		}
+	}
+
+	props["S3.value"] = "${secret:unknown}"
+	e.ClearPool("TestPropsResolver")
+	_ = e.UpdatePlan("TestPropsResolver", func(plan *running.Plan) {
+		plan.Props = props
+	})
+
+	output = <-e.ExecPlan("TestPropsResolver", context.Background())
+	if output.Err == nil || !strings.Contains(output.Err.Error(), "secret unknown not found") {
+		t.Errorf("expect resolve error, got %v", output.Err)
+	}
+}
@@ -0,0 +1,123 @@
+package test
+
+import (
+	"bytes"
+	"context"
+	"encoding/gob"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/symphony09/running"
+	"github.com/symphony09/running/common"
+)
+
+type Order struct {
+	ID string
+
+	Amount float64
+
+	Items []string
+}
+
+func TestSerializableState(t *testing.T) {
+	registry := common.NewStateTypeRegistry()
+	if err := registry.Register("test.Order", Order{}); err != nil {
+		t.Error(err)
+		return
+	}
+
+	if err := registry.Register("test.Order", &Order{}); err == nil {
+		t.Errorf("expect error when register name for another type")
+	}
+
+	e := running.NewDefaultEngine()
+	e.StateBuilder = common.NewSerializableStateBuilder(registry)
+	e.RegisterNodeBuilder("Order", common.NewSimpleStatefulNodeBuilder(func(ctx context.Context, state running.State) {
+		state.Update("order", Order{ID: "o1", Amount: 9.5, Items: []string{"a", "b"}})
+		state.Update("count", 2)
+		state.Update("timeout", time.Second)
+		state.Update("none", nil)
+	}))
+
+	plan := running.NewPlan(nil, nil, running.AddNodes("Order", "O"), running.LinkNodes("O"))
+	if err := e.RegisterPlan("TestSerializableState", plan); err != nil {
+		t.Errorf("register plan failed, err=%s", err.Error())
+		return
+	}
+
+	output := <-e.ExecPlan("TestSerializableState", context.Background())
+	if output.Err != nil {
+		t.Errorf("exec plan failed, err=%s", output.Err.Error())
+		return
+	}
+
+	check := func(format string, state running.State) {
+		if order, _ := state.Query("order"); order.(Order).ID != "o1" || order.(Order).Items[1] != "b" {
+			t.Errorf("%s: expect order decoded, got %#v", format, order)
+		}
+
+		if count, _ := state.Query("count"); count != 2 {
+			t.Errorf("%s: expect count int 2, got %#v", format, count)
+		}
+
+		if timeout, _ := state.Query("timeout"); timeout != time.Second {
+			t.Errorf("%s: expect timeout 1s, got %#v", format, timeout)
+		}
+
+		if none, ok := state.Query("none"); !ok || none != nil {
+			t.Errorf("%s: expect nil value kept, got %#v", format, none)
+		}
+	}
+
+	data, err := json.Marshal(output.State)
+	if err != nil {
+		t.Errorf("marshal state failed, err=%s", err.Error())
+		return
+	}
+
+	decoded := common.NewSerializableState(registry)
+	decoded.Update("stale", 1)
+	if err = json.Unmarshal(data, decoded); err != nil {
+		t.Errorf("unmarshal state failed, err=%s", err.Error())
+		return
+	}
+
+	check("json", decoded)
+	if _, ok := decoded.Query("stale"); ok {
+		t.Errorf("expect keys replaced when decode")
+	}
+
+	var buf bytes.Buffer
+	if err = gob.NewEncoder(&buf).Encode(output.State.(*common.SerializableState)); err != nil {
+		t.Errorf("gob encode state failed, err=%s", err.Error())
+		return
+	}
+
+	decoded = common.NewSerializableState(registry)
+	if err = gob.NewDecoder(&buf).Decode(decoded); err != nil {
+		t.Errorf("gob decode state failed, err=%s", err.Error())
+		return
+	}
+
+	check("gob", decoded)
+
+	if err = json.Unmarshal(data, common.NewSerializableState(nil)); !errors.Is(err, common.ErrStateTypeNotRegistered) {
+		t.Errorf("expect unknown type name error, got %v", err)
+	}
+
+	output.State.Update("unregistered", struct{}{})
+	if _, err = json.Marshal(output.State); !errors.Is(err, common.ErrStateTypeNotRegistered) {
+		t.Errorf("expect unregistered type error, got %v", err)
+	}
+
+	// element types of interface containers can not be kept, so they are not registered by default
+	for _, value := range []interface{}{[]interface{}{1}, map[string]interface{}{"a": 1}} {
+		state := common.NewSerializableState(nil)
+		state.Update("value", value)
+		if _, err = json.Marshal(state); !errors.Is(err, common.ErrStateTypeNotRegistered) {
+			t.Errorf("expect %T not registered, got %v", value, err)
+		}
+	}
+}
@@ -58,3 +58,54 @@ func TestSerialization(t *testing.T) {
 		}
 	}
 }
+
+func TestUpdateJsonPlan(t *testing.T) {
+	plan := running.NewPlan(running.StandardProps{"S1.key": "k", "S1.value": "v"}, nil,
+		running.AddNodes("Serial", "L1"),
+		running.AddNodes("BaseTest", "B1"),
+		running.AddNodes("SetState", "S1"),
+		running.MergeNodes("L1", "B1"),
+		running.MarkNodes("mark", "S1"),
+		running.SLinkNodes("L1", "S1"))
+
+	data, err := json.Marshal(plan)
+	if err != nil {
+		t.Errorf("marshal plan failed, err=%s", err.Error())
+		return
+	}
+
+	if err = running.LoadPlanFromJson("TestUpdateJsonPlan", data, nil); err != nil {
+		t.Errorf("load plan failed, err=%s", err.Error())
+		return
+	}
+
+	err = running.UpdatePlan("TestUpdateJsonPlan", func(plan *running.Plan) {
+		plan.Options = append(plan.Options,
+			running.AddNodes("BaseTest", "B2"),
+			running.LinkNodes("S1", "B2"))
+	})
+	if err != nil {
+		t.Errorf("update plan failed, err=%s", err.Error())
+		return
+	}
+
+	running.ClearPool("TestUpdateJsonPlan")
+
+	output := <-running.ExecPlan("TestUpdateJsonPlan", context.Background())
+	if output.Err != nil {
+		t.Errorf("exec plan failed, err=%s", output.Err.Error())
+		return
+	}
+
+	sum := utils.GetRunSummary(output.State)
+	for _, name := range []string{"L1.B1", "S1", "B2"} {
+		if len(sum.Logs[name]) != 1 {
+			t.Errorf("expect %s run once, but got %d", name, len(sum.Logs[name]))
+		}
+	}
+
+	info := running.Inspect(running.Global).DescribePlan("TestUpdateJsonPlan")
+	if !info.LabelMap["mark"] {
+		t.Errorf("expect label kept after update, got %v", info.LabelMap)
+	}
+}
@@ -0,0 +1,136 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/symphony09/running"
+	"github.com/symphony09/running/common"
+	"github.com/symphony09/running/utils"
+)
+
+func TestStateExtensions(t *testing.T) {
+	states := map[string]running.State{
+		"StandardState": running.NewStandardState(),
+		"ChanState":     common.NewChanState(),
+		"UnsafeState":   common.NewUnsafeState(),
+		"HookState":     common.NewHookState(nil),
+		"OverlayState":  common.NewOverlayState(running.NewStandardState(), running.NewStandardState()),
+	}
+
+	for name, state := range states {
+		state.Update("a", 1)
+		state.Update("b", 2)
+		state.Update("c", 3)
+
+		if !utils.DeleteState(state, "b") {
+			t.Errorf("%s: expect delete supported", name)
+		}
+
+		if _, ok := state.Query("b"); ok {
+			t.Errorf("%s: expect b deleted", name)
+		}
+
+		keys, ok := utils.StateKeys(state)
+		if !ok || len(keys) != 2 || keys[0] != "a" || keys[1] != "c" {
+			t.Errorf("%s: expect keys [a c], got %v", name, keys)
+		}
+
+		snapshot, ok := utils.SnapshotState(state)
+		if !ok || len(snapshot) != 2 || snapshot["a"] != 1 || snapshot["c"] != 3 {
+			t.Errorf("%s: expect snapshot map[a:1 c:3], got %v", name, snapshot)
+		}
+
+		state.Update("a", 10)
+		if snapshot["a"] != 1 {
+			t.Errorf("%s: expect snapshot not changed by update", name)
+		}
+
+		count := 0
+		utils.RangeState(state, func(key string, value interface{}) bool {
+			count++
+			return false
+		})
+		if count != 1 {
+			t.Errorf("%s: expect range stopped after first key, got %d", name, count)
+		}
+	}
+
+	if utils.DeleteState(&CountState{}, "a") {
+		t.Errorf("expect delete not supported by state without Delete")
+	}
+
+	if _, ok := utils.StateKeys(&CountState{}); ok {
+		t.Errorf("expect keys not supported by state without Range or Snapshot")
+	}
+
+	// wrapping states report unsupported if the wrapped states do not support
+	hook := common.NewHookState(&CountState{})
+	if utils.DeleteState(hook, "a") {
+		t.Errorf("expect delete not supported by HookState with base without Delete")
+	}
+
+	if _, ok := utils.SnapshotState(hook); ok {
+		t.Errorf("expect snapshot not supported by HookState with base without Range or Snapshot")
+	}
+
+	overlay := common.NewOverlayState(&CountState{}, running.NewStandardState())
+	if !utils.DeleteState(overlay, "a") {
+		t.Errorf("expect delete supported by OverlayState")
+	}
+
+	if _, ok := utils.StateKeys(overlay); ok {
+		t.Errorf("expect keys not supported by OverlayState with lower without Range or Snapshot")
+	}
+}
+
+func TestOverlayStateTombstone(t *testing.T) {
+	upper, lower := running.NewStandardState(), running.NewStandardState()
+	overlay := common.NewOverlayState(lower, upper)
+
+	lower.Update("a", 1)
+	lower.Update("b", 2)
+	overlay.Update("c", 3)
+
+	overlay.(running.Deleter).Delete("a")
+	overlay.(running.Deleter).Delete("c")
+
+	if _, ok := overlay.Query("a"); ok {
+		t.Errorf("expect a of lower hidden by tombstone")
+	}
+
+	if v, _ := lower.Query("a"); v != 1 {
+		t.Errorf("expect lower not changed, got a=%v", v)
+	}
+
+	keys, _ := utils.StateKeys(overlay)
+	if len(keys) != 1 || keys[0] != "b" {
+		t.Errorf("expect keys [b], got %v", keys)
+	}
+
+	overlay.Transform("a", func(from interface{}) interface{} {
+		if from != nil {
+			t.Errorf("expect deleted key transformed from nil, got %v", from)
+		}
+		return 5
+	})
+
+	if v, _ := overlay.Query("a"); v != 5 {
+		t.Errorf("expect a=5 after transform, got %v", v)
+	}
+}
+
+type CountState struct {
+	count int
+}
+
+func (state *CountState) Query(key string) (interface{}, bool) {
+	return state.count, true
+}
+
+func (state *CountState) Update(key string, value interface{}) {
+	state.count++
+}
+
+func (state *CountState) Transform(key string, transform running.TransformStateFunc) {
+	state.count++
+}
@@ -0,0 +1,175 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/symphony09/running"
+	"github.com/symphony09/running/utils"
+)
+
+type SumNode struct {
+	running.Base
+
+	A int `running:"in:a"`
+
+	B float64 `running:"in:b;optional"`
+
+	Sum float64 `running:"out:sum"`
+}
+
+func (node *SumNode) Run(ctx context.Context) {
+	node.Sum = float64(node.A) + node.B
+}
+
+type SumCluster struct {
+	running.Base
+
+	Count int `running:"out:count"`
+}
+
+func (cluster *SumCluster) Run(ctx context.Context) {
+	for _, node := range cluster.SubNodes {
+		node.Run(ctx)
+	}
+	cluster.Count = len(cluster.SubNodes)
+}
+
+func TestStateIO(t *testing.T) {
+	e := running.NewDefaultEngine()
+	if err := utils.RegisterNodes(e, &SumNode{}, &SumCluster{}); err != nil {
+		t.Error(err)
+		return
+	}
+
+	plan := running.NewPlan(nil, nil,
+		running.AddNodes("SumNode", "S1", "S2"),
+		running.AddNodes("SumCluster", "L"),
+		running.MergeNodes("L", "S2"),
+		running.LinkNodes("S1", "L"))
+
+	if err := e.RegisterPlan("TestStateIO", plan); err != nil {
+		t.Errorf("register plan failed, err=%s", err.Error())
+		return
+	}
+
+	exec := func(values map[string]interface{}) running.Output {
+		state := running.NewStandardState()
+		for k, v := range values {
+			state.Update(k, v)
+		}
+
+		ctx := context.WithValue(context.Background(), running.CtxKey, running.CtxParams{State: state})
+		return <-e.ExecPlan("TestStateIO", ctx)
+	}
+
+	output := exec(map[string]interface{}{"a": float64(1), "b": 2})
+	if output.Err != nil {
+		t.Errorf("exec plan failed, err=%s", output.Err.Error())
+		return
+	}
+
+	if sum, _ := output.State.Query("sum"); sum != float64(3) {
+		t.Errorf("expect sum 3, got %v", sum)
+	}
+
+	if count, _ := output.State.Query("count"); count != 1 {
+		t.Errorf("expect count 1, got %v", count)
+	}
+
+	output = exec(map[string]interface{}{"a": 1})
+	if output.Err != nil {
+		t.Errorf("exec plan failed, err=%s", output.Err.Error())
+	} else if sum, _ := output.State.Query("sum"); sum != float64(1) {
+		t.Errorf("expect sum 1 when optional b not set, got %v", sum)
+	}
+
+	// node is skipped and the error is reported as output error
+	output = exec(map[string]interface{}{"b": 2})
+	var nodeErr *running.NodeError
+	if !errors.As(output.Err, &nodeErr) || nodeErr.NodeName != "S1" ||
+		!errors.Is(output.Err, utils.ErrStateKeyNotFound) || !errors.Is(output.Err, running.ErrNodeFailed) {
+		t.Errorf("expect state key not found of S1, got %v", output.Err)
+	} else if _, ok := output.State.Query("sum"); ok {
+		t.Errorf("expect no output when input key not set")
+	}
+
+	// errors of sub-nodes are reported too
+	state := running.NewStandardState()
+	ctx := context.WithValue(context.Background(), running.CtxKey, running.CtxParams{State: state, SkipNodes: []string{"S1"}})
+	output = <-e.ExecPlan("TestStateIO", ctx)
+	if !errors.As(output.Err, &nodeErr) || nodeErr.NodeName != "L.S2" || !errors.Is(output.Err, utils.ErrStateKeyNotFound) {
+		t.Errorf("expect state key not found of L.S2, got %v", output.Err)
+	}
+
+	output = exec(map[string]interface{}{"a": "one"})
+	if output.Err == nil || !strings.Contains(output.Err.Error(), "expect int") {
+		t.Errorf("expect error when state value can not be converted, got %v", output.Err)
+	}
+}
+
+type CloneableSumNode struct {
+	running.Base
+
+	A int `running:"in:a"`
+
+	Sum int `running:"out:sum"`
+}
+
+var cloneableSumNodeClones int32
+
+func (node *CloneableSumNode) Run(ctx context.Context) {
+	node.Sum = node.A + 1
+}
+
+func (node *CloneableSumNode) Clone() running.Node {
+	atomic.AddInt32(&cloneableSumNodeClones, 1)
+
+	cloned := new(CloneableSumNode)
+	cloned.SetName(node.Name())
+	return cloned
+}
+
+func TestStateIOClone(t *testing.T) {
+	e := running.NewDefaultEngine()
+	if err := utils.RegisterNodes(e, &CloneableSumNode{}); err != nil {
+		t.Error(err)
+		return
+	}
+
+	plan := running.NewPlan(nil, []running.Node{},
+		running.AddNodes("CloneableSumNode", "S1"),
+		running.ReUseNodes("S1"),
+		running.LinkNodes("S1"))
+
+	if err := e.RegisterPlan("TestStateIOClone", plan); err != nil {
+		t.Errorf("register plan failed, err=%s", err.Error())
+		return
+	}
+
+	for i := 1; i <= 2; i++ {
+		state := running.NewStandardState()
+		state.Update("a", i)
+
+		ctx := context.WithValue(context.Background(), running.CtxKey, running.CtxParams{State: state})
+		output := <-e.ExecPlan("TestStateIOClone", ctx)
+		if output.Err != nil {
+			t.Errorf("exec plan failed, err=%s", output.Err.Error())
+			return
+		}
+
+		if sum, _ := output.State.Query("sum"); sum != i+1 {
+			t.Errorf("expect sum %d, got %v", i+1, sum)
+		}
+
+		// next exec builds a new worker with the reused node
+		e.ClearPool("TestStateIOClone")
+	}
+
+	if n := atomic.LoadInt32(&cloneableSumNodeClones); n == 0 {
+		t.Errorf("expect reused node cloned")
+	}
+}
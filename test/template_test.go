@@ -0,0 +1,92 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/symphony09/running"
+)
+
+func TestPlanTemplate(t *testing.T) {
+	template := running.PlanTemplate{
+		Params: []running.PropSchema{
+			{Name: "shard", Type: running.PropTypeInt, Required: true},
+			{Name: "setter_type", Type: running.PropTypeString, Default: "SetState"},
+			{Name: "value", Type: running.PropTypeAny},
+		},
+		Plan: []byte(`{
+			"Props": {
+				"Shard${shard}.key": "shard_${shard}",
+				"Shard${shard}.value": "${value}",
+				"Env.key": "${env:HOME}"
+			},
+			"Graph": [{"Node": {"Name": "Shard${shard}", "Type": "${setter_type}"}}]
+		}`),
+	}
+
+	if err := running.RegisterPlanTemplate("TestPlanTemplate", template); err != nil {
+		t.Errorf("register template failed, err=%s", err.Error())
+		return
+	}
+
+	for i, value := range []interface{}{true, 2.5} {
+		name := []string{"TestPlanTemplate1", "TestPlanTemplate2"}[i]
+		if err := running.InstantiatePlan("TestPlanTemplate", name,
+			map[string]interface{}{"shard": i + 1, "value": value}); err != nil {
+			t.Errorf("instantiate plan failed, err=%s", err.Error())
+			return
+		}
+
+		output := <-running.ExecPlan(name, context.Background())
+		if output.Err != nil {
+			t.Errorf("exec plan failed, err=%s", output.Err.Error())
+			return
+		}
+
+		key := []string{"shard_1", "shard_2"}[i]
+		if v, _ := output.State.Query(key); v != value {
+			t.Errorf("expect %s=%v, got %v", key, value, v)
+		}
+	}
+
+	// re-instantiate with different params takes effect immediately
+	if err := running.InstantiatePlan("TestPlanTemplate", "TestPlanTemplate1",
+		map[string]interface{}{"shard": 1, "value": "updated"}); err != nil {
+		t.Errorf("instantiate plan failed, err=%s", err.Error())
+		return
+	}
+
+	output := <-running.ExecPlan("TestPlanTemplate1", context.Background())
+	if output.Err != nil {
+		t.Errorf("exec plan failed, err=%s", output.Err.Error())
+		return
+	}
+
+	if v, _ := output.State.Query("shard_1"); v != "updated" {
+		t.Errorf("expect shard_1=updated, got %v", v)
+	}
+
+	templates := running.Inspect(running.Global).GetPlanTemplates()
+	instances := templates["TestPlanTemplate"].Instances
+	if len(instances) != 2 || instances["TestPlanTemplate2"]["shard"] != 2 ||
+		instances["TestPlanTemplate1"]["setter_type"] != "SetState" {
+		t.Errorf("unexpected instances %v", instances)
+	}
+
+	if err := running.InstantiatePlan("TestPlanTemplate", "TestPlanTemplate3",
+		map[string]interface{}{"shard": "x", "other": 1}); err == nil {
+		t.Errorf("expect error for invalid params")
+	}
+
+	if err := running.InstantiatePlan("NotExist", "TestPlanTemplate3", nil); !errors.Is(err, running.ErrPlanTemplateNotFound) {
+		t.Errorf("expect ErrPlanTemplateNotFound, got %v", err)
+	}
+
+	err := running.RegisterPlanTemplate("TestPlanTemplateInvalid", running.PlanTemplate{
+		Plan: []byte(`{"Graph": [{"Node": {"Name": "${name}", "Type": "SetState"}}]}`),
+	})
+	if err == nil {
+		t.Errorf("expect error for undeclared param")
+	}
+}
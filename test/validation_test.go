@@ -0,0 +1,64 @@
+package test
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/symphony09/running"
+)
+
+func TestValidateJsonPlan(t *testing.T) {
+	data := []byte(`{
+		"Props": {},
+		"Graph": [
+			{"Node": {"Name": "A", "Type": "T"}, "NextNodes": ["B", "X"]},
+			{"Node": {"Name": "B", "Type": "T"}, "NextNodes": ["C"]},
+			{"Node": {"Name": "C", "Type": "Loop", "SubNodes": [{"Name": "S", "Type": "T1"}]}, "NextNodes": ["B"]},
+			{"Node": {"Name": "D", "Type": "Loop", "SubNodes": [{"Name": "S", "Type": "T2"}]}},
+			{"Node": {"Name": "A", "Type": "T"}},
+			{"Node": {"Name": "E", "Labels": [1]}}
+		]
+	}`)
+
+	err := running.ValidateJsonPlan(data)
+
+	var errs running.PlanValidationErrors
+	if !errors.As(err, &errs) {
+		t.Errorf("expect validation errors, but got %v", err)
+		return
+	}
+
+	expect := map[string]bool{
+		"$.Graph[0].NextNodes[1]: unknown vertex X":    true,
+		"$.Graph[1]: found cycle between nodes: [B C]": true,
+		`$.Graph[3].Node.SubNodes[0]: node S defined twice with different types, type "T1" at $.Graph[2].Node.SubNodes[0] and type "T2" here`: true,
+		"$.Graph[4].Node.Name: duplicate vertex name A, first defined at $.Graph[0]":                                                          true,
+		"$.Graph[5].Node.Labels[0]: expect string, got number":                                                                                true,
+		"$.Graph[5].Node.Type: type of node E is required":                                                                                    true,
+	}
+
+	if len(errs) != len(expect) {
+		t.Errorf("expect %d problems, but got %d: %v", len(expect), len(errs), errs)
+	}
+
+	for _, e := range errs {
+		if !expect[e.Error()] {
+			t.Errorf("unexpected problem: %s", e.Error())
+		}
+	}
+
+	plan := &running.Plan{}
+	if err = json.Unmarshal(data, plan); !errors.As(err, &errs) {
+		t.Errorf("expect unmarshal plan return validation errors, but got %v", err)
+	}
+
+	if running.JsonPlanSchema == "" {
+		t.Error("expect json plan schema")
+	}
+
+	var schema map[string]interface{}
+	if err = json.Unmarshal([]byte(running.JsonPlanSchema), &schema); err != nil {
+		t.Errorf("invalid json plan schema, err=%s", err.Error())
+	}
+}
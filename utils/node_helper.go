@@ -4,17 +4,23 @@ import (
 	"fmt"
 	"reflect"
 	"runtime"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/symphony09/running"
 )
 
 // RegisterNodes auto register node builder, field with running tag will be set
 // tag `running:"name"` to get node name
-// tag `running:"prop:key"` to get prop value of the key
+// tag `running:"prop:key"` to get prop value of the key, decoded as DecodeProps does,
+// followed by `;default:value`, `;required` and `;desc:text` to declare props schema of the builder
+// tag `running:"in:key"` to set field from state value of the key before run, converted as props,
+// node is skipped and the error is reported by running.ReportNodeError if the key is not set, unless followed by `;optional`
+// tag `running:"out:key"` to write field to state of the key after run
 func RegisterNodes(e *running.Engine, nodes ...running.Node) error {
 	for _, node := range nodes {
-		name, builder, props, err := parseNode(node)
+		name, builder, props, schemas, err := parseNode(node)
 		if err != nil {
 			return err
 		} else {
@@ -36,6 +42,7 @@ func RegisterNodes(e *running.Engine, nodes ...running.Node) error {
 			info.Note = fmt.Sprintf("Property Map: %+v\nAuto regitered by util of runnning.", props)
 
 			e.SetNodeBuilderInfo(name, info)
+			e.SetNodeBuilderProps(name, schemas)
 		}
 	}
 
@@ -44,7 +51,7 @@ func RegisterNodes(e *running.Engine, nodes ...running.Node) error {
 
 // RegisterNodeWithTypeName similar to RegisterNodes, but specify the type name of node
 func RegisterNodeWithTypeName(e *running.Engine, typeName string, node running.Node) error {
-	_, builder, props, err := parseNode(node)
+	_, builder, props, schemas, err := parseNode(node)
 	if err != nil {
 		return err
 	} else {
@@ -66,6 +73,7 @@ func RegisterNodeWithTypeName(e *running.Engine, typeName string, node running.N
 		info.Note = fmt.Sprintf("Propery Map: %+v \nAuto regitered by util of runnning.", props)
 
 		e.SetNodeBuilderInfo(typeName, info)
+		e.SetNodeBuilderProps(typeName, schemas)
 
 		return nil
 	}
@@ -75,7 +83,8 @@ type Uninitialized interface {
 	Init() error
 }
 
-func parseNode(node running.Node) (typeName string, builder running.BuildNodeFunc, props map[string]reflect.Type, err error) {
+func parseNode(node running.Node) (typeName string, builder running.BuildNodeFunc, props map[string]reflect.Type,
+	schemas []running.PropSchema, err error) {
 	nodeType := reflect.TypeOf(node)
 	if nodeType.Kind() == reflect.Ptr {
 		nodeType = nodeType.Elem()
@@ -90,28 +99,32 @@ func parseNode(node running.Node) (typeName string, builder running.BuildNodeFun
 
 	typeName = strings.TrimPrefix(nodeType.Name(), nodeType.PkgPath())
 	autowired := map[string]string{}
+	inputs, outputs := parseStateFields(nodeType)
 	var nameField, baseField string
 
 	for i := 0; i < nodeType.NumField(); i++ {
 		f := nodeType.Field(i)
 		tag, ok := f.Tag.Lookup("running")
 		if ok {
-			tagItems := strings.Split(tag, ";")
-			for _, item := range tagItems {
-				k, v, found := strings.Cut(item, ":")
-				if found {
-					switch strings.TrimSpace(k) {
-					case "prop":
-						propName := strings.TrimSpace(v)
-						autowired[f.Name] = propName
-						props[propName] = f.Type
-					}
-				} else {
-					switch strings.TrimSpace(k) {
-					case "name":
-						nameField = f.Name
+			if pt, isProp := parsePropTag(tag); isProp {
+				autowired[f.Name] = pt.Name
+				props[pt.Name] = f.Type
+				schema := running.PropSchema{Name: pt.Name, Type: propType(f.Type), Required: pt.Required, Description: pt.Desc}
+
+				if pt.HasDefault {
+					if schema.Default, err = parseDefault(pt.Default, f.Type); err != nil {
+						err = fmt.Errorf("invalid default value of prop %s, %w", schema.Name, err)
+						return
 					}
 				}
+
+				schemas = append(schemas, schema)
+			}
+
+			for _, item := range strings.Split(tag, ";") {
+				if strings.TrimSpace(item) == "name" {
+					nameField = f.Name
+				}
 			}
 		} else if f.Anonymous && f.Type == reflect.TypeOf(running.Base{}) {
 			baseField = f.Name
@@ -127,6 +140,13 @@ func parseNode(node running.Node) (typeName string, builder running.BuildNodeFun
 			}
 		}
 
+		for _, field := range append(inputs, outputs...) {
+			if !nodeVal.Elem().FieldByName(field.Field).CanSet() {
+				err = fmt.Errorf("state field %s cannot be set", field.Field)
+				return
+			}
+		}
+
 		if nameField != "" {
 			field := nodeVal.Elem().FieldByName(nameField)
 
@@ -152,20 +172,8 @@ func parseNode(node running.Node) (typeName string, builder running.BuildNodeFun
 		newNodeVal := reflect.New(nodeType)
 		newNode = newNodeVal.Interface().(running.Node)
 
-		for fieldName, propName := range autowired {
-			val, found := props.SubGet(name, propName)
-			if !found {
-				continue
-			}
-
-			field := newNodeVal.Elem().FieldByName(fieldName)
-
-			if field.Type() != reflect.TypeOf(val) {
-				err = fmt.Errorf("prop field type = %v, prop value type = %v", field.Type(), reflect.TypeOf(val))
-				return
-			}
-
-			field.Set(reflect.ValueOf(val))
+		if err = DecodeProps(props, name, newNodeVal.Interface()); err != nil {
+			return
 		}
 
 		if nameField != "" {
@@ -177,7 +185,13 @@ func parseNode(node running.Node) (typeName string, builder running.BuildNodeFun
 		}
 
 		if uninitializedNode, ok := newNode.(Uninitialized); ok {
-			err = uninitializedNode.Init()
+			if err = uninitializedNode.Init(); err != nil {
+				return
+			}
+		}
+
+		if len(inputs) > 0 || len(outputs) > 0 {
+			newNode = withStateIO(newNode, newNodeVal.Elem(), inputs, outputs)
 		}
 
 		return
@@ -185,3 +199,73 @@ func parseNode(node running.Node) (typeName string, builder running.BuildNodeFun
 
 	return
 }
+
+func propType(typ reflect.Type) string {
+	switch typ.Kind() {
+	case reflect.String:
+		return running.PropTypeString
+	case reflect.Bool:
+		return running.PropTypeBool
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if typ == reflect.TypeOf(time.Duration(0)) {
+			return typ.String()
+		}
+		return running.PropTypeInt
+	case reflect.Float32, reflect.Float64:
+		return running.PropTypeFloat
+	case reflect.Interface:
+		return running.PropTypeAny
+	default:
+		return typ.String()
+	}
+}
+
+// parseDefault parse default value in tag as the field type
+func parseDefault(raw string, typ reflect.Type) (interface{}, error) {
+	value := reflect.New(typ).Elem()
+
+	switch {
+	case typ == reflect.TypeOf(time.Duration(0)):
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return nil, err
+		}
+		value.SetInt(int64(d))
+	case typ.Kind() == reflect.String:
+		value.SetString(raw)
+	case typ.Kind() == reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return nil, err
+		}
+		value.SetBool(b)
+	case value.CanInt():
+		i, err := strconv.ParseInt(raw, 10, typ.Bits())
+		if err != nil {
+			return nil, err
+		}
+		value.SetInt(i)
+	case value.CanUint():
+		u, err := strconv.ParseUint(raw, 10, typ.Bits())
+		if err != nil {
+			return nil, err
+		}
+		value.SetUint(u)
+	case value.CanFloat():
+		f, err := strconv.ParseFloat(raw, typ.Bits())
+		if err != nil {
+			return nil, err
+		}
+		value.SetFloat(f)
+	case typ.Kind() == reflect.Interface:
+		return raw, nil
+	case typ.Kind() == reflect.Slice, typ.Kind() == reflect.Array, typ.Kind() == reflect.Map,
+		typ.Kind() == reflect.Struct, typ.Kind() == reflect.Ptr:
+		return parseJsonDefault(raw, typ)
+	default:
+		return nil, fmt.Errorf("default value not supported for type %v", typ)
+	}
+
+	return value.Interface(), nil
+}
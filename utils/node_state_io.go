@@ -0,0 +1,255 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/symphony09/running"
+)
+
+// ErrStateKeyNotFound state key of input field is not set when node run
+var ErrStateKeyNotFound = errors.New("state key not found")
+
+// stateField field of node struct bound to state key by tag `running:"in:key"` or `running:"out:key"`
+type stateField struct {
+	Field string
+
+	Key string
+
+	Optional bool
+}
+
+// parseStateTag parse in and out items of running tag, `;optional` allows input key not set
+func parseStateTag(field string, tag string) (in, out *stateField) {
+	var optional bool
+
+	for _, item := range strings.Split(tag, ";") {
+		k, v, found := strings.Cut(item, ":")
+		switch {
+		case found && strings.TrimSpace(k) == "in":
+			in = &stateField{Field: field, Key: strings.TrimSpace(v)}
+		case found && strings.TrimSpace(k) == "out":
+			out = &stateField{Field: field, Key: strings.TrimSpace(v)}
+		case !found && strings.TrimSpace(k) == "optional":
+			optional = true
+		}
+	}
+
+	if in != nil {
+		in.Optional = optional
+	}
+
+	return
+}
+
+// parseStateFields find fields bound to state keys of struct type
+func parseStateFields(typ reflect.Type) (inputs, outputs []stateField) {
+	for i := 0; i < typ.NumField(); i++ {
+		f := typ.Field(i)
+
+		in, out := parseStateTag(f.Name, f.Tag.Get("running"))
+		if in != nil {
+			inputs = append(inputs, *in)
+		}
+		if out != nil {
+			outputs = append(outputs, *out)
+		}
+	}
+
+	return
+}
+
+// ReadStateFields set fields of the struct pointed by out with tag `running:"in:key"` from state, converted as DecodeProps does.
+// error is ErrStateKeyNotFound if the key is not set, unless followed by `;optional`.
+func ReadStateFields(state running.State, out interface{}) error {
+	v := reflect.ValueOf(out)
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("state can only be read into non-nil struct pointer, got %T", out)
+	}
+
+	inputs, _ := parseStateFields(v.Elem().Type())
+	return readStateFields(state, v.Elem(), inputs)
+}
+
+// WriteStateFields update state with fields of the struct or struct pointer with tag `running:"out:key"`
+func WriteStateFields(state running.State, in interface{}) error {
+	v := reflect.Indirect(reflect.ValueOf(in))
+	if v.Kind() != reflect.Struct {
+		return fmt.Errorf("state can only be written from struct or struct pointer, got %T", in)
+	}
+
+	_, outputs := parseStateFields(v.Type())
+	writeStateFields(state, v, outputs)
+	return nil
+}
+
+func readStateFields(state running.State, v reflect.Value, inputs []stateField) error {
+	for _, in := range inputs {
+		field := v.FieldByName(in.Field)
+		if !field.CanSet() {
+			return fmt.Errorf("state field %s cannot be set", in.Field)
+		}
+
+		var raw interface{}
+		var found bool
+		if state != nil {
+			raw, found = state.Query(in.Key)
+		}
+
+		if !found {
+			if !in.Optional {
+				return fmt.Errorf("%w, key: %s", ErrStateKeyNotFound, in.Key)
+			}
+
+			field.Set(reflect.Zero(field.Type()))
+			continue
+		}
+
+		if errs := decodeValue(raw, field, in.Key); len(errs) > 0 {
+			return fmt.Errorf("failed to set field %s from state, %s: %s", in.Field, errs[0].Path, errs[0].Msg)
+		}
+	}
+
+	return nil
+}
+
+func writeStateFields(state running.State, v reflect.Value, outputs []stateField) {
+	if state == nil {
+		return
+	}
+
+	for _, out := range outputs {
+		state.Update(out.Key, v.FieldByName(out.Field).Interface())
+	}
+}
+
+// withStateIO wrap node to set input fields from state before run and write output fields back after run.
+// wrapped node is cloneable only if node is cloneable.
+func withStateIO(node running.Node, value reflect.Value, inputs, outputs []stateField) running.Node {
+	ioNode := &_StateIONode{Node: node, Value: value, Inputs: inputs, Outputs: outputs}
+	_, cloneable := node.(running.Cloneable)
+
+	if _, ok := node.(running.Wrapper); ok {
+		if cloneable {
+			return &_StateIOCloneableWrapper{&_StateIOWrapper{ioNode}}
+		}
+		return &_StateIOWrapper{ioNode}
+	} else if _, ok = node.(running.Cluster); ok {
+		if cloneable {
+			return &_StateIOCloneableCluster{&_StateIOCluster{ioNode}}
+		}
+		return &_StateIOCluster{ioNode}
+	}
+
+	if cloneable {
+		return &_StateIOCloneableNode{ioNode}
+	}
+
+	return ioNode
+}
+
+type _StateIONode struct {
+	running.Node
+
+	// Value struct value of node
+	Value reflect.Value
+
+	Inputs, Outputs []stateField
+
+	State running.State
+}
+
+// Run skip the node if input key not set or value can not be converted, the error is reported by running.ReportNodeError
+func (node *_StateIONode) Run(ctx context.Context) {
+	if err := readStateFields(node.State, node.Value, node.Inputs); err != nil {
+		running.ReportNodeError(ctx, node.Name(), err)
+		return
+	}
+
+	node.Node.Run(ctx)
+
+	writeStateFields(node.State, node.Value, node.Outputs)
+}
+
+func (node *_StateIONode) Bind(state running.State) {
+	node.State = state
+
+	if statefulNode, ok := node.Node.(running.Stateful); ok {
+		statefulNode.Bind(state)
+	}
+}
+
+func (node *_StateIONode) Reset() {
+	node.State = nil
+
+	node.Node.Reset()
+}
+
+func (node *_StateIONode) Revert(ctx context.Context) {
+	if reversibleNode, ok := node.Node.(running.Reversible); ok {
+		reversibleNode.Revert(ctx)
+	}
+}
+
+func (node *_StateIONode) Reconfigure(props running.Props) error {
+	if reconfigurableNode, ok := node.Node.(running.Reconfigurable); ok {
+		return reconfigurableNode.Reconfigure(props)
+	}
+
+	return fmt.Errorf("node %s is not reconfigurable", node.Name())
+}
+
+// clone clone the node and bind the same state fields, the clone is not wrapped if it is not a pointer to the node struct
+func (node *_StateIONode) clone() running.Node {
+	cloned := node.Node.(running.Cloneable).Clone()
+
+	v := reflect.ValueOf(cloned)
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Type() != node.Value.Type() {
+		return cloned
+	}
+
+	return withStateIO(cloned, v.Elem(), node.Inputs, node.Outputs)
+}
+
+type _StateIOCloneableNode struct {
+	*_StateIONode
+}
+
+func (node *_StateIOCloneableNode) Clone() running.Node {
+	return node.clone()
+}
+
+type _StateIOCluster struct {
+	*_StateIONode
+}
+
+func (node *_StateIOCluster) Inject(nodes []running.Node) {
+	node.Node.(running.Cluster).Inject(nodes)
+}
+
+type _StateIOWrapper struct {
+	*_StateIONode
+}
+
+func (node *_StateIOWrapper) Wrap(target running.Node) {
+	node.Node.(running.Wrapper).Wrap(target)
+}
+
+type _StateIOCloneableCluster struct {
+	*_StateIOCluster
+}
+
+func (node *_StateIOCloneableCluster) Clone() running.Node {
+	return node.clone()
+}
+
+type _StateIOCloneableWrapper struct {
+	*_StateIOWrapper
+}
+
+func (node *_StateIOCloneableWrapper) Clone() running.Node {
+	return node.clone()
+}
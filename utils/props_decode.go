@@ -0,0 +1,363 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/symphony09/running"
+)
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// propTag parsed tag `running:"prop:key;default:value;required;desc:text"`
+type propTag struct {
+	Name string
+
+	Default string
+
+	HasDefault bool
+
+	Required bool
+
+	Desc string
+}
+
+// parsePropTag parse running tag of field, return false if tag has no prop item
+func parsePropTag(tag string) (propTag, bool) {
+	var pt propTag
+	var isProp bool
+
+	for _, item := range strings.Split(tag, ";") {
+		k, v, found := strings.Cut(item, ":")
+		if found {
+			switch strings.TrimSpace(k) {
+			case "prop":
+				pt.Name, isProp = strings.TrimSpace(v), true
+			case "default":
+				pt.Default, pt.HasDefault = strings.TrimSpace(v), true
+			case "desc":
+				pt.Desc = strings.TrimSpace(v)
+			}
+		} else if strings.TrimSpace(k) == "required" {
+			pt.Required = true
+		}
+	}
+
+	return pt, isProp
+}
+
+// DecodeProps set fields of the struct pointed by out with props of the node, fields with tag `running:"prop:key"` are set,
+// followed by `;default:value` to use default when prop not set (json for slices, maps and structs) and `;required` to report error.
+// values are converted to field types: numbers between numeric types without loss, durations from strings like "1s" or nanoseconds,
+// structs from maps, slices and maps element by element.
+// fields of nested structs are matched by tag `running:"prop:key"`, json tag or field name, case-insensitively.
+// all errors are returned as running.PropsErrors.
+func DecodeProps(props running.Props, nodeName string, out interface{}) error {
+	v := reflect.ValueOf(out)
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("props of %s can only be decoded into non-nil struct pointer, got %T", nodeName, out)
+	}
+
+	if props == nil {
+		props = running.EmptyProps{}
+	}
+
+	lookup := func(key string) (interface{}, bool) {
+		return props.SubGet(nodeName, key)
+	}
+
+	var errs running.PropsErrors
+	for _, err := range decodeStruct(lookup, v.Elem(), "", false) {
+		errs = append(errs, running.PropsError{NodePath: nodeName, Prop: err.Path, Msg: err.Msg})
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+
+	return nil
+}
+
+type _DecodeError struct {
+	Path string
+
+	Msg string
+}
+
+// decodeStruct set fields of struct by lookup, untagged fields are set only if nested
+func decodeStruct(lookup func(key string) (interface{}, bool), v reflect.Value, path string, nested bool) (errs []_DecodeError) {
+	typ := v.Type()
+
+	for i := 0; i < typ.NumField(); i++ {
+		f := typ.Field(i)
+
+		tag, isProp := parsePropTag(f.Tag.Get("running"))
+		if !isProp {
+			if !nested {
+				continue
+			}
+
+			if f.Anonymous && f.Type.Kind() == reflect.Struct {
+				errs = append(errs, decodeStruct(lookup, v.Field(i), path, nested)...)
+				continue
+			}
+
+			if !f.IsExported() {
+				continue
+			}
+
+			tag.Name = f.Name
+			if name, _, _ := strings.Cut(f.Tag.Get("json"), ","); name == "-" {
+				continue
+			} else if name != "" {
+				tag.Name = name
+			}
+		}
+
+		fieldPath := tag.Name
+		if path != "" {
+			fieldPath = path + "." + tag.Name
+		}
+
+		field := v.Field(i)
+		if !field.CanSet() {
+			errs = append(errs, _DecodeError{Path: fieldPath, Msg: fmt.Sprintf("field %s cannot be set", f.Name)})
+			continue
+		}
+
+		raw, found := lookup(tag.Name)
+		if !found {
+			if tag.Required {
+				errs = append(errs, _DecodeError{Path: fieldPath, Msg: "required but not set"})
+				continue
+			}
+
+			if !tag.HasDefault {
+				continue
+			}
+
+			var err error
+			if raw, err = parseDefault(tag.Default, f.Type); err != nil {
+				errs = append(errs, _DecodeError{Path: fieldPath, Msg: fmt.Sprintf("invalid default value, %v", err)})
+				continue
+			}
+		}
+
+		errs = append(errs, decodeValue(raw, field, fieldPath)...)
+	}
+
+	return
+}
+
+// decodeValue convert raw value to type of v and set it
+func decodeValue(raw interface{}, v reflect.Value, path string) []_DecodeError {
+	typ := v.Type()
+
+	if raw == nil {
+		v.Set(reflect.Zero(typ))
+		return nil
+	}
+
+	rv := reflect.ValueOf(raw)
+	mismatch := []_DecodeError{{Path: path, Msg: fmt.Sprintf("expect %v, got %T", typ, raw)}}
+
+	if typ == durationType {
+		if s, ok := raw.(string); ok {
+			d, err := time.ParseDuration(s)
+			if err != nil {
+				return []_DecodeError{{Path: path, Msg: err.Error()}}
+			}
+
+			v.SetInt(int64(d))
+			return nil
+		}
+	}
+
+	if rv.Type().AssignableTo(typ) {
+		v.Set(rv)
+		return nil
+	}
+
+	switch typ.Kind() {
+	case reflect.Ptr:
+		elem := reflect.New(typ.Elem())
+		if errs := decodeValue(raw, elem.Elem(), path); len(errs) > 0 {
+			return errs
+		}
+		v.Set(elem)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, ok := toInt(rv)
+		if !ok || v.OverflowInt(i) {
+			return mismatch
+		}
+		v.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		u, ok := toUint(rv)
+		if !ok || v.OverflowUint(u) {
+			return mismatch
+		}
+		v.SetUint(u)
+	case reflect.Float32, reflect.Float64:
+		f, ok := toFloat(rv)
+		if !ok || v.OverflowFloat(f) {
+			return mismatch
+		}
+		v.SetFloat(f)
+	case reflect.String:
+		if rv.Kind() != reflect.String {
+			return mismatch
+		}
+		v.SetString(rv.String())
+	case reflect.Bool:
+		if rv.Kind() != reflect.Bool {
+			return mismatch
+		}
+		v.SetBool(rv.Bool())
+	case reflect.Slice:
+		if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+			return mismatch
+		}
+
+		slice := reflect.MakeSlice(typ, rv.Len(), rv.Len())
+		var errs []_DecodeError
+		for i := 0; i < rv.Len(); i++ {
+			errs = append(errs, decodeValue(rv.Index(i).Interface(), slice.Index(i), fmt.Sprintf("%s[%d]", path, i))...)
+		}
+		if len(errs) > 0 {
+			return errs
+		}
+		v.Set(slice)
+	case reflect.Array:
+		if (rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array) || rv.Len() != typ.Len() {
+			return mismatch
+		}
+
+		array := reflect.New(typ).Elem()
+		var errs []_DecodeError
+		for i := 0; i < rv.Len(); i++ {
+			errs = append(errs, decodeValue(rv.Index(i).Interface(), array.Index(i), fmt.Sprintf("%s[%d]", path, i))...)
+		}
+		if len(errs) > 0 {
+			return errs
+		}
+		v.Set(array)
+	case reflect.Map:
+		if rv.Kind() != reflect.Map {
+			return mismatch
+		}
+
+		m := reflect.MakeMapWithSize(typ, rv.Len())
+		var errs []_DecodeError
+		iter := rv.MapRange()
+		for iter.Next() {
+			keyPath := fmt.Sprintf("%s.%v", path, iter.Key().Interface())
+
+			key := reflect.New(typ.Key()).Elem()
+			if keyErrs := decodeValue(iter.Key().Interface(), key, keyPath); len(keyErrs) > 0 {
+				errs = append(errs, keyErrs...)
+				continue
+			}
+
+			elem := reflect.New(typ.Elem()).Elem()
+			if elemErrs := decodeValue(iter.Value().Interface(), elem, keyPath); len(elemErrs) > 0 {
+				errs = append(errs, elemErrs...)
+				continue
+			}
+
+			m.SetMapIndex(key, elem)
+		}
+		if len(errs) > 0 {
+			return errs
+		}
+		v.Set(m)
+	case reflect.Struct:
+		if rv.Kind() != reflect.Map || rv.Type().Key().Kind() != reflect.String {
+			return mismatch
+		}
+
+		values := make(map[string]interface{}, rv.Len())
+		folded := make(map[string]interface{}, rv.Len())
+		iter := rv.MapRange()
+		for iter.Next() {
+			values[iter.Key().String()] = iter.Value().Interface()
+			folded[strings.ToLower(iter.Key().String())] = iter.Value().Interface()
+		}
+
+		lookup := func(key string) (value interface{}, found bool) {
+			if value, found = values[key]; !found {
+				value, found = folded[strings.ToLower(key)]
+			}
+			return
+		}
+
+		s := reflect.New(typ).Elem()
+		if errs := decodeStruct(lookup, s, path, true); len(errs) > 0 {
+			return errs
+		}
+		v.Set(s)
+	case reflect.Interface:
+		if !rv.Type().Implements(typ) {
+			return mismatch
+		}
+		v.Set(rv)
+	default:
+		return mismatch
+	}
+
+	return nil
+}
+
+func toInt(rv reflect.Value) (int64, bool) {
+	switch {
+	case rv.CanInt():
+		return rv.Int(), true
+	case rv.CanUint():
+		return int64(rv.Uint()), rv.Uint() <= math.MaxInt64
+	case rv.CanFloat():
+		f := rv.Float()
+		return int64(f), f == math.Trunc(f) && f >= math.MinInt64 && f < math.MaxInt64
+	default:
+		return 0, false
+	}
+}
+
+func toUint(rv reflect.Value) (uint64, bool) {
+	switch {
+	case rv.CanInt():
+		return uint64(rv.Int()), rv.Int() >= 0
+	case rv.CanUint():
+		return rv.Uint(), true
+	case rv.CanFloat():
+		f := rv.Float()
+		return uint64(f), f == math.Trunc(f) && f >= 0 && f < math.MaxUint64
+	default:
+		return 0, false
+	}
+}
+
+func toFloat(rv reflect.Value) (float64, bool) {
+	switch {
+	case rv.CanInt():
+		return float64(rv.Int()), true
+	case rv.CanUint():
+		return float64(rv.Uint()), true
+	case rv.CanFloat():
+		return rv.Float(), true
+	default:
+		return 0, false
+	}
+}
+
+// parseJsonDefault parse default value in json for composite types
+func parseJsonDefault(raw string, typ reflect.Type) (interface{}, error) {
+	value := reflect.New(typ)
+	if err := json.Unmarshal([]byte(raw), value.Interface()); err != nil {
+		return nil, err
+	}
+
+	return value.Elem().Interface(), nil
+}
@@ -1,6 +1,10 @@
 package utils
 
-import "github.com/symphony09/running"
+import (
+	"sort"
+
+	"github.com/symphony09/running"
+)
 
 type StatesHelper struct {
 	State running.State
@@ -59,3 +63,110 @@ func (helper StatesHelper) GetBytes(key string) (value []byte) {
 	value, _ = raw.([]byte)
 	return
 }
+
+// PartialState state wrapping other states, which implements Delete, Range and Snapshot
+// but they work only if the wrapped states support them. helpers of state check it before calling.
+type PartialState interface {
+	// CanDelete return true if Delete is supported
+	CanDelete() bool
+
+	// CanRange return true if Range and Snapshot are supported
+	CanRange() bool
+}
+
+// CanDeleteState return true if state is running.Deleter and supports it, see PartialState
+func CanDeleteState(state running.State) bool {
+	if partial, ok := state.(PartialState); ok && !partial.CanDelete() {
+		return false
+	}
+
+	_, ok := state.(running.Deleter)
+	return ok
+}
+
+// CanRangeState return true if state is running.Ranger or running.Snapshotter and supports it, see PartialState
+func CanRangeState(state running.State) bool {
+	if partial, ok := state.(PartialState); ok && !partial.CanRange() {
+		return false
+	}
+
+	if _, ok := state.(running.Ranger); ok {
+		return true
+	}
+
+	_, ok := state.(running.Snapshotter)
+	return ok
+}
+
+// DeleteState delete the key if state is running.Deleter, return false if not supported
+func DeleteState(state running.State, key string) bool {
+	if !CanDeleteState(state) {
+		return false
+	}
+
+	if deleter, ok := state.(running.Deleter); ok {
+		deleter.Delete(key)
+		return true
+	}
+
+	return false
+}
+
+// RangeState call f for each key and value if state is running.Ranger or running.Snapshotter, return false if not supported
+func RangeState(state running.State, f func(key string, value interface{}) bool) bool {
+	if !CanRangeState(state) {
+		return false
+	}
+
+	if ranger, ok := state.(running.Ranger); ok {
+		ranger.Range(f)
+		return true
+	}
+
+	if snapshotter, ok := state.(running.Snapshotter); ok {
+		for key, value := range snapshotter.Snapshot() {
+			if !f(key, value) {
+				break
+			}
+		}
+		return true
+	}
+
+	return false
+}
+
+// SnapshotState copy all keys and values if state is running.Snapshotter or running.Ranger, return false if not supported.
+// copy by Range is not consistent if state is updated while ranging.
+func SnapshotState(state running.State) (map[string]interface{}, bool) {
+	if !CanRangeState(state) {
+		return nil, false
+	}
+
+	if snapshotter, ok := state.(running.Snapshotter); ok {
+		return snapshotter.Snapshot(), true
+	}
+
+	if ranger, ok := state.(running.Ranger); ok {
+		snapshot := make(map[string]interface{})
+		ranger.Range(func(key string, value interface{}) bool {
+			snapshot[key] = value
+			return true
+		})
+		return snapshot, true
+	}
+
+	return nil, false
+}
+
+// StateKeys return sorted keys of state, return false if not supported, see RangeState
+func StateKeys(state running.State) ([]string, bool) {
+	var keys []string
+
+	ok := RangeState(state, func(key string, value interface{}) bool {
+		keys = append(keys, key)
+		return true
+	})
+	sort.Strings(keys)
+
+	return keys, ok
+}
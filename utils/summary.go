@@ -58,3 +58,25 @@ func AddLog(state running.State, name string, start, end time.Time, msg string,
 		}
 	})
 }
+
+// GetExecTrace convert run summary in state to execution trace, which can be rendered by PlanInfo.ToDOT and ToMermaid.
+// node with any error log is marked as failed, duration is the sum of all logs.
+func GetExecTrace(state running.State) running.ExecTrace {
+	trace := make(running.ExecTrace)
+
+	for name, logs := range GetRunSummary(state).Logs {
+		nodeTrace := running.NodeTrace{Status: running.TraceStatusSuccess}
+
+		for _, log := range logs {
+			nodeTrace.Duration += log.End.Sub(log.Start)
+
+			if log.Err != nil {
+				nodeTrace.Status = running.TraceStatusFailed
+			}
+		}
+
+		trace[name] = nodeTrace
+	}
+
+	return trace
+}